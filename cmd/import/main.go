@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"messaging-server/internal/database"
+	"messaging-server/internal/importer"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// import enqueues the messages of a CSV or NDJSON file, the same way the
+// POST /api/v1/messages/import endpoint does, and prints the import report.
+//
+//	go run ./cmd/import -file campaign.csv
+//	go run ./cmd/import -format ndjson < campaign.ndjson
+//	go run ./cmd/import -tenant billing -file campaign.csv
+func main() {
+	file := flag.String("file", "", "file to import; reads stdin when empty")
+	format := flag.String("format", "", "csv or ndjson; defaults to the file extension")
+	tenant := flag.String("tenant", models.DefaultTenant, "tenant owning the imported messages")
+	flag.Parse()
+
+	log.InitLogger()
+
+	// initialize Postgres connection
+	if err := database.ConnectPostgres(); err != nil {
+		log.Logger.Fatalf("failed to connect to Postgres: %v", err)
+	}
+
+	if _, err := database.PostgresConnection.FetchTenant(context.Background(), *tenant); err != nil {
+		log.Logger.Fatalf("cannot import for tenant %q: %v", *tenant, err)
+	}
+
+	input := os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Logger.Fatalf("failed to open %s: %v", *file, err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	fileFormat := importer.DetectFormat(*format, *file, "")
+	if fileFormat == "" {
+		log.Logger.Fatal("cannot tell the format; pass -format csv or -format ndjson")
+	}
+
+	// an interrupted import stores nothing
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := importer.Import(ctx, *tenant, input, fileFormat)
+	if err != nil {
+		log.Logger.Fatalf("import failed, nothing was stored: %v", err)
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	if report.Rejected > 0 {
+		os.Exit(2)
+	}
+}
@@ -5,16 +5,31 @@ import (
 	"messaging-server/internal/configs"
 	"messaging-server/internal/cron"
 	"messaging-server/internal/database"
+	"messaging-server/internal/dispatch"
+	"messaging-server/internal/events"
 	"messaging-server/internal/jobs"
+	"messaging-server/internal/leader"
 	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
 	"messaging-server/internal/router"
+	"messaging-server/internal/settings"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 )
 
+// @securityDefinitions.apikey  AdminToken
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer " followed by ADMIN_TOKEN
+
+// @securityDefinitions.apikey  ApiKey
+// @in                          header
+// @name                        X-API-Key
+// @description                 An API key; "Authorization: Bearer <key>" is accepted as well
 func main() {
 
 	log.InitLogger()
@@ -29,19 +44,158 @@ func main() {
 		log.Logger.Fatalf("failed to connect to Redis: %v", err)
 	}
 
-	// initialize cron job
-	cronJob, err := cron.NewCron(jobs.SendMessageJob)
+	cfg := configs.AppConfig
+
+	// elect the replica running the scheduled jobs
+	if cfg.LeaderElection && cfg.LeaderLeaseTTL <= 0 {
+		log.Logger.Fatalf("LEADER_LEASE_TTL must be > 0, got %d", cfg.LeaderLeaseTTL)
+	}
+	elector := leader.NewElector(cfg.LeaderElection, cfg.InstanceID, time.Duration(cfg.LeaderLeaseTTL)*time.Second)
+
+	// initialize the scheduler hosting every job
+	scheduler := cron.NewScheduler(elector)
+	stuckAfter := time.Duration(cfg.StuckJobThreshold) * time.Second
+
+	// register the send job
+	switch cfg.SendMode {
+	case models.SendModeBatch, models.SendModeDrain, models.SendModeAdaptive:
+	default:
+		log.Logger.Fatalf("SEND_MODE must be %q, %q or %q, got %q",
+			models.SendModeBatch, models.SendModeDrain, models.SendModeAdaptive, cfg.SendMode)
+	}
+	switch cfg.ReconcilePolicy {
+	case models.ReconcilePolicyRepair, models.ReconcilePolicyReport:
+	default:
+		log.Logger.Fatalf("RECONCILE_POLICY must be %q or %q, got %q",
+			models.ReconcilePolicyRepair, models.ReconcilePolicyReport, cfg.ReconcilePolicy)
+	}
+	if cfg.StaleClaimAfter <= cfg.SendJobTimeout {
+		log.Logger.Warningf("STALE_CLAIM_AFTER (%ds) should exceed SEND_JOB_TIMEOUT (%ds), or claims of running sends are released",
+			cfg.StaleClaimAfter, cfg.SendJobTimeout)
+	}
+	sendSchedule, err := cron.NewSchedule(cfg.CronSchedule, cfg.CronTimezone, cfg.CronInterval)
+	if err != nil {
+		log.Logger.Fatalf("invalid send job schedule: %v", err)
+	}
+	if err := scheduler.Register(jobs.SendJobName, jobs.SendMessageJob, sendSchedule, cron.Options{
+		MaxConcurrent: cfg.MaxConcurrentJobs,
+		Timeout:       time.Duration(cfg.SendJobTimeout) * time.Second,
+		StuckAfter:    stuckAfter,
+	}); err != nil {
+		log.Logger.Fatalf("failed to register send job: %v", err)
+	}
+
+	// register the job projecting sent outcomes into Redis
+	projectorSchedule, err := cron.Every(cfg.ProjectorInterval)
+	if err != nil {
+		log.Logger.Fatalf("invalid projector job schedule: %v", err)
+	}
+	if err := scheduler.Register(jobs.ProjectJobName, jobs.ProjectSentRecordsJob, projectorSchedule, cron.Options{
+		MaxConcurrent: 1,
+		Timeout:       time.Duration(cfg.ProjectJobTimeout) * time.Second,
+		StuckAfter:    stuckAfter,
+	}); err != nil {
+		log.Logger.Fatalf("failed to register projector job: %v", err)
+	}
+
+	// register the job reconciling Redis and Postgres
+	reconcileSchedule, err := cron.NewSchedule(cfg.ReconcileSchedule, cfg.CronTimezone, cfg.ReconcileInterval)
 	if err != nil {
-		log.Logger.Fatalf("failed to create cron job: %v", err)
+		log.Logger.Fatalf("invalid reconcile job schedule: %v", err)
+	}
+	if err := scheduler.Register(jobs.ReconcileJobName, jobs.ReconcileJob, reconcileSchedule, cron.Options{
+		MaxConcurrent: 1,
+		Timeout:       time.Duration(cfg.ReconcileJobTimeout) * time.Second,
+		StuckAfter:    stuckAfter,
+	}); err != nil {
+		log.Logger.Fatalf("failed to register reconcile job: %v", err)
+	}
+
+	// register the hourly job purging the job history
+	if cfg.JobRunRetention > 0 {
+		purgeSchedule, err := cron.NewSchedule("", cfg.CronTimezone, 3600)
+		if err != nil {
+			log.Logger.Fatalf("invalid purge job schedule: %v", err)
+		}
+		if err := scheduler.Register(jobs.PurgeJobName, jobs.PurgeJobRunsJob, purgeSchedule, cron.Options{
+			MaxConcurrent: 1,
+			Timeout:       time.Duration(cfg.PurgeJobTimeout) * time.Second,
+			StuckAfter:    stuckAfter,
+		}); err != nil {
+			log.Logger.Fatalf("failed to register purge job: %v", err)
+		}
+	}
+
+	// apply the runtime settings persisted through the admin API
+	sendJob, _ := scheduler.Job(jobs.SendJobName)
+	if err := settings.Load(context.Background(), sendJob); err != nil {
+		log.Logger.Fatalf("failed to load runtime settings: %v", err)
+	}
+
+	// ADMIN_TOKEN passes every scope, so a published example value must not be accepted
+	switch strings.ToLower(cfg.AdminToken) {
+	case "change-me", "changeme", "admin", "secret", "token":
+		log.Logger.Fatalf("ADMIN_TOKEN is set to the placeholder %q; set a random secret or leave it unset", cfg.AdminToken)
+	}
+	if cfg.APIKeyAuth && cfg.AdminToken == "" {
+		log.Logger.Warningln("API_KEY_AUTH is enabled without ADMIN_TOKEN; no API key can be created")
 	}
 
 	log.Logger.Infoln("Starting messaging server...")
 
 	// initialize Gin router with all endpoints
-	r := router.SetupRouter(cronJob)
+	broker := events.NewBroker()
+	r := router.SetupRouter(scheduler, elector, broker)
+
+	// runs started while leading must not outlive the lease; a new leader first
+	// catches up with settings changed through the other replicas
+	elector.OnChange(func(isLeader bool) {
+		if !isLeader {
+			scheduler.CancelRuns()
+			return
+		}
+		go func() {
+			if err := settings.Reload(context.Background(), sendJob); err != nil {
+				log.Logger.Errorf("failed to reload runtime settings: %v", err)
+			}
+		}()
+	})
+
+	// immediately start the cron jobs, the stuck-run watchdog and the election;
+	// followers keep their schedules armed and skip runs until they lead
+	scheduler.Start()
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	go scheduler.Watch(watchCtx)
+	go settings.Watch(watchCtx, sendJob)
+
+	// wake the send job up as soon as messages are inserted; the schedule stays as a safety net
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	if cfg.EventDispatch {
+		dispatcher := dispatch.NewDispatcher(sendJob, time.Duration(cfg.DispatchDebounce)*time.Millisecond)
+		go dispatcher.Run(dispatchCtx)
+		go func() {
+			if err := database.ListenForInserts(dispatchCtx, dispatcher.Notify); err != nil {
+				log.Logger.Errorf("event dispatch disabled, relying on the schedule: %v", err)
+			}
+		}()
+	}
 
-	// immediately start the cron job
-	cronJob.Start()
+	// publish the message events of the jobs in the background and fan the
+	// events of every replica out to the event stream clients
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	publisherDone := make(chan struct{})
+	go func() {
+		events.RunPublisher(eventsCtx)
+		close(publisherDone)
+	}()
+	go broker.Run(eventsCtx)
+
+	electionCtx, stopElection := context.WithCancel(context.Background())
+	electionDone := make(chan struct{})
+	go func() {
+		elector.Run(electionCtx)
+		close(electionDone)
+	}()
 
 	// create HTTP server
 	srv := &http.Server{
@@ -62,11 +216,28 @@ func main() {
 	<-quit
 	log.Logger.Infoln("Shutting down server...")
 
-	// stop cron job
-	cronJob.Stop()
+	stopWatch()
+	stopDispatch()
+	gracePeriod := time.Duration(configs.AppConfig.ServerGracePeriod) * time.Second
+
+	// stop cron jobs, draining in-flight runs and cancelling them once the grace period is over
+	jobsCtx, cancelJobs := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancelJobs()
+	if err := scheduler.Shutdown(jobsCtx); err != nil {
+		log.Logger.Warningf("in-flight jobs cancelled after grace period: %v", err)
+	}
+
+	// hand the lease over to another replica right away
+	stopElection()
+	<-electionDone
+
+	// end the event streams, which would otherwise keep the HTTP server busy,
+	// and publish the events the jobs left queued
+	stopEvents()
+	<-publisherDone
 
 	// shutdown HTTP server with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(configs.AppConfig.ServerGracePeriod)*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Logger.Fatalf("Server forced to shutdown: %v", err)
@@ -32,9 +32,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/cron/control": {
+        "/api/v1/admin/keys": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Lists every API key, including revoked ones, with its scopes and when it was last used.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "API keys fetched successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKey"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "invalid or missing admin token"
+                    },
+                    "500": {
+                        "description": "failed to fetch API keys"
+                    }
+                }
+            },
             "post": {
-                "description": "Start or stop the cron based on the \"action\" field.",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Creates an API key of a tenant (default: \"default\") granted the given scopes (messages:read,\nmessages:write, cron:read, cron:admin). Requests made with the key only see the messages of its\ntenant; cron scopes are reserved to the default tenant. The key is only returned in this\nresponse; only its SHA-256 digest is stored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -42,102 +79,2067 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Cron"
+                    "Admin"
                 ],
-                "summary": "Control cron job",
+                "summary": "Create API key",
                 "parameters": [
                     {
-                        "description": "start or stop",
+                        "type": "string",
+                        "description": "Who creates the key",
+                        "name": "X-Admin-Actor",
+                        "in": "header"
+                    },
+                    {
+                        "description": "key name and scopes",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CronRequest"
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "API key created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatedAPIKey"
                         }
+                    },
+                    "400": {
+                        "description": "invalid API key request or unknown tenant"
+                    },
+                    "401": {
+                        "description": "invalid or missing admin token"
+                    },
+                    "500": {
+                        "description": "failed to create API key"
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Revokes an API key; requests using it are rejected right away. The key stays listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Revoke API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Cron job started"
+                        "description": "API key revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKey"
+                        }
                     },
-                    "202": {
-                        "description": "Cron job will be stopped"
+                    "400": {
+                        "description": "invalid API key ID"
+                    },
+                    "401": {
+                        "description": "invalid or missing admin token"
+                    },
+                    "404": {
+                        "description": "API key not found or already revoked"
+                    },
+                    "500": {
+                        "description": "failed to revoke API key"
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/reconciliation": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Returns the drift found between Redis and Postgres by the last reconciliation run.\nThe report spans every tenant, so it requires ADMIN_TOKEN.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reconciliation report",
+                "responses": {
+                    "200": {
+                        "description": "Reconciliation report fetched successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReconciliationReport"
+                        }
+                    },
+                    "404": {
+                        "description": "no reconciliation run yet"
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/settings": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Returns the send job interval, fetch limit and concurrency limit in effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Runtime settings",
+                "responses": {
+                    "200": {
+                        "description": "Runtime settings fetched successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.RuntimeSettings"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid or missing admin token"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Changes the send job interval (seconds), fetch limit (at most ADAPTIVE_MAX_BATCH) and concurrency limit without a restart.\nOmitted fields are left unchanged. Changes are persisted, survive restarts and are audited\nunder the X-Admin-Actor header (defaults to \"admin\").",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update runtime settings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Who makes the change",
+                        "name": "X-Admin-Actor",
+                        "in": "header"
+                    },
+                    {
+                        "description": "settings to change",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SettingsUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Runtime settings updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.RuntimeSettings"
+                        }
                     },
                     "400": {
-                        "description": "Invalid request payload"
+                        "description": "invalid setting"
+                    },
+                    "401": {
+                        "description": "invalid or missing admin token"
+                    },
+                    "500": {
+                        "description": "failed to update runtime settings"
                     }
                 }
             }
         },
-        "/api/v1/list/sent-messages": {
+        "/api/v1/admin/settings/audit": {
             "get": {
-                "description": "Retrieves all messages that have been sent.",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Lists every runtime setting change newest first, with its old and new value, actor and time.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Messages"
+                    "Admin"
+                ],
+                "summary": "Runtime settings audit",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List sent messages",
                 "responses": {
                     "200": {
-                        "description": "Messages fetched successfully",
+                        "description": "Settings audit fetched successfully",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Message"
+                                "$ref": "#/definitions/models.SettingAudit"
                             }
                         }
                     },
+                    "400": {
+                        "description": "invalid query parameter"
+                    },
+                    "401": {
+                        "description": "invalid or missing admin token"
+                    },
                     "500": {
-                        "description": "failed to fetch messages"
+                        "description": "failed to fetch settings audit"
                     }
                 }
             }
         },
-        "/health": {
+        "/api/v1/admin/tenants": {
             "get": {
-                "description": "Simple endpoint to verify the service is running.",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Lists every tenant with its provider configuration.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Base"
+                    "Admin"
                 ],
-                "summary": "Health check",
+                "summary": "List tenants",
                 "responses": {
                     "200": {
-                        "description": "Healthy!"
+                        "description": "Tenants fetched successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Tenant"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "invalid or missing admin token"
+                    },
+                    "500": {
+                        "description": "failed to fetch tenants"
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "models.CronRequest": {
-            "type": "object",
-            "properties": {
-                "action": {
-                    "type": "string"
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Creates a tenant with its own messages and API keys. Messages of a tenant with a webhookUrl are\nsent there instead of WEBHOOK_URL; a rateLimit (messages per second, 0 = unlimited) gives the\ntenant its own token bucket instead of sharing PROVIDER_RATE_LIMIT.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Create tenant",
+                "parameters": [
+                    {
+                        "description": "tenant ID and configuration",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateTenantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Tenant created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tenant"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid tenant"
+                    },
+                    "401": {
+                        "description": "invalid or missing admin token"
+                    },
+                    "409": {
+                        "description": "tenant already exists"
+                    },
+                    "500": {
+                        "description": "failed to create tenant"
+                    }
                 }
             }
         },
-        "models.Message": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "isSent": {
-                    "type": "boolean"
-                },
-                "phoneNumber": {
-                    "type": "string"
+        "/api/v1/admin/tenants/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Replaces the name and provider configuration of a tenant; omitted provider settings fall back\nto the deployment defaults. The send job picks the change up on its next run.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Update tenant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "tenant configuration",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TenantConfig"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tenant updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Tenant"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid tenant"
+                    },
+                    "401": {
+                        "description": "invalid or missing admin token"
+                    },
+                    "404": {
+                        "description": "tenant not found"
+                    },
+                    "500": {
+                        "description": "failed to update tenant"
+                    }
                 }
             }
+        },
+        "/api/v1/cron/control": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Start, stop or immediately run the job named in the \"job\" field (defaults to \"send\").\nStopping drains in-flight runs unless \"mode\" is \"cancel\".\n\"run\" (or its alias \"trigger\") executes the job right away through the same concurrency limit\nand returns the run ID to poll at /api/v1/cron/runs/{id}; \"overrides.fetchLimit\" changes the\nnumber of messages claimed per batch by that send run only, up to ADAPTIVE_MAX_BATCH.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cron"
+                ],
+                "summary": "Control cron job",
+                "parameters": [
+                    {
+                        "description": "job name and start, stop or run",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CronRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cron job started"
+                    },
+                    "202": {
+                        "description": "Cron job will be stopped / run started"
+                    },
+                    "400": {
+                        "description": "Invalid request payload"
+                    },
+                    "404": {
+                        "description": "Job not found"
+                    },
+                    "409": {
+                        "description": "Max concurrency reached, job stopped or instance is not the leader"
+                    }
+                }
+            }
+        },
+        "/api/v1/cron/jobs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Lists every registered job with its schedule, running state, next run and in-flight count,\nalong with the leader election status under \"leader\".",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cron"
+                ],
+                "summary": "List cron jobs",
+                "responses": {
+                    "200": {
+                        "description": "Jobs fetched successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.JobStatus"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/cron/jobs/{name}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Returns the schedule, running state, next run and in-flight count of a job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cron"
+                ],
+                "summary": "Get cron job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job fetched successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.JobStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "job not found"
+                    }
+                }
+            }
+        },
+        "/api/v1/cron/leader": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Returns whether this instance leads, the current leader and its fencing token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cron"
+                ],
+                "summary": "Leader status",
+                "responses": {
+                    "200": {
+                        "description": "Leader status fetched successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.LeaderStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/cron/runs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Lists job runs newest first, optionally filtered by job, status, skipped flag and start time range.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cron"
+                ],
+                "summary": "List job runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job name",
+                        "name": "job",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "running, succeeded, failed, timed_out, cancelled or skipped",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only skipped (true) or executed (false) runs",
+                        "name": "skipped",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Runs started at or after this RFC3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Runs started before this RFC3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of runs to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job runs fetched successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.JobRun"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter"
+                    },
+                    "500": {
+                        "description": "failed to fetch job runs"
+                    }
+                }
+            }
+        },
+        "/api/v1/cron/runs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Returns the status, counters and error summary of a job run.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cron"
+                ],
+                "summary": "Get job run",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job run fetched successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.JobRun"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid run ID"
+                    },
+                    "404": {
+                        "description": "job run not found"
+                    },
+                    "500": {
+                        "description": "failed to fetch job run"
+                    }
+                }
+            }
+        },
+        "/api/v1/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Pushes message lifecycle events (claimed, sent, failed) as Server-Sent Events while they happen.\nEvery event carries its ID; reconnecting with the Last-Event-ID header, or the last_event_id\nquery parameter, first replays the events missed in between. A client falling too far\nbehind is disconnected and resumes the same way.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Message event stream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only events of messages to this recipient",
+                        "name": "phone_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated event types: claimed, sent, failed, delivered",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resume after this event",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Stream token from POST /api/v1/events/token",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resume after this event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter"
+                    },
+                    "500": {
+                        "description": "failed to replay events"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/token": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Issues a token for clients that can not set headers on the event stream request, like\nEventSource and browser WebSockets. It is passed as the token query parameter, opens a single\nstream of the tenant of the request and expires after a minute, so API keys never end up in URLs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Create stream token",
+                "responses": {
+                    "201": {
+                        "description": "Stream token created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.StreamToken"
+                        }
+                    },
+                    "500": {
+                        "description": "failed to create stream token"
+                    }
+                }
+            }
+        },
+        "/api/v1/events/ws": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "WebSocket equivalent of GET /api/v1/events: every lifecycle event is sent as a JSON text\nmessage. Reconnecting with last_event_id replays the events missed in between. The server\ncloses with code 1013 when the client fell too far behind or the server shuts down.",
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Message event WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only events of messages to this recipient",
+                        "name": "phone_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated event types: claimed, sent, failed, delivered",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resume after this event",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Stream token from POST /api/v1/events/token",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching to the WebSocket protocol",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter"
+                    },
+                    "500": {
+                        "description": "failed to replay events"
+                    }
+                }
+            }
+        },
+        "/api/v1/list/sent-messages": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Deprecated: use GET /api/v1/messages?status=sent. Takes the same query parameters, with the\nstatus fixed to sent.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "List sent messages",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages fetched successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Message"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter"
+                    },
+                    "500": {
+                        "description": "failed to fetch messages"
+                    }
+                }
+            }
+        },
+        "/api/v1/messages": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Lists messages newest first, filtered by status, recipient, creation time range and content.\nPass the returned next_cursor as \"cursor\" to fetch the following page; it is null on the last page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "List messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, sending, sent or cancelled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recipient",
+                        "name": "phone_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Messages created at or after this RFC3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Messages created before this RFC3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive text contained in the content",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages fetched successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Message"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter"
+                    },
+                    "500": {
+                        "description": "failed to fetch messages"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Validates and enqueues a message; the ID is generated by the server. The message is sent\nby the next send run.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Create message",
+                "parameters": [
+                    {
+                        "description": "content and E.164 phone_number",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Message created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request payload"
+                    },
+                    "429": {
+                        "description": "rate limit exceeded"
+                    },
+                    "500": {
+                        "description": "failed to create message"
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Streams the messages matching the filters of GET /api/v1/messages, oldest first, as a CSV or\nNDJSON download with chunked transfer. The export reads a consistent snapshot through a\nserver-side cursor; a response cut short by an error is aborted instead of completed.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Export messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (default) or ndjson",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, sending, sent or cancelled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recipient",
+                        "name": "phone_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Messages created at or after this RFC3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Messages created before this RFC3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive text contained in the content",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages exported"
+                    },
+                    "400": {
+                        "description": "invalid query parameter"
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Streams a CSV (header with phone_number and content columns) or NDJSON upload into the queue.\nThe upload is either the request body or the \"file\" field of a multipart form; the format is\ntaken from the \"format\" query parameter, the file name or the content type. Invalid rows are\nrejected and listed by line number; the valid rows are stored together.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Import messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or ndjson",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "upload, when sent as a multipart form",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages imported",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid upload"
+                    },
+                    "413": {
+                        "description": "upload too large"
+                    },
+                    "429": {
+                        "description": "rate limit exceeded"
+                    },
+                    "500": {
+                        "description": "failed to import messages"
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/lookup": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Returns the messages with the given IDs (at most 500) like GET /api/v1/messages/{id}, in the\norder requested. Unknown IDs are listed under \"missing\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Look up messages",
+                "parameters": [
+                    {
+                        "description": "message IDs",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageLookupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages fetched successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MessageDetails"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request payload"
+                    },
+                    "500": {
+                        "description": "failed to fetch messages"
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Returns the stored message along with the provider message ID and send time, read from Redis\nwhile its record exists and from Postgres once it expired.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message fetched successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "message not found"
+                    },
+                    "500": {
+                        "description": "failed to fetch message"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Cancels a message that has not been claimed for sending yet, so it is never sent. Send the ETag\nof GET /api/v1/messages/{id} as If-Match to make sure nobody changed the message in the meantime.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Cancel message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being cancelled",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message cancelled successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid If-Match header"
+                    },
+                    "404": {
+                        "description": "message not found"
+                    },
+                    "409": {
+                        "description": "message is no longer pending"
+                    },
+                    "412": {
+                        "description": "message version mismatch"
+                    },
+                    "500": {
+                        "description": "failed to cancel message"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Changes a message that has not been claimed for sending yet. Send the ETag of\nGET /api/v1/messages/{id} as If-Match to make sure nobody changed the message in the meantime.\nThe edited message gets a new idempotency key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Edit message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "content and/or phone_number",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EditMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageDetails"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request payload"
+                    },
+                    "404": {
+                        "description": "message not found"
+                    },
+                    "409": {
+                        "description": "message is no longer pending"
+                    },
+                    "412": {
+                        "description": "message version mismatch"
+                    },
+                    "500": {
+                        "description": "failed to update message"
+                    }
+                }
+            }
+        },
+        "/api/v1/messages/{id}/attempts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Retrieves every provider request made for a message, including status, latency and response.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "List delivery attempts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Delivery attempts fetched successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DeliveryAttempt"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "message not found"
+                    },
+                    "500": {
+                        "description": "failed to fetch delivery attempts"
+                    }
+                }
+            }
+        },
+        "/api/v1/providers/rate": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "Returns the configured limit and the observed send rate of every provider.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Providers"
+                ],
+                "summary": "Provider send rate",
+                "responses": {
+                    "200": {
+                        "description": "Provider rates fetched successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProviderRateStats"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Simple endpoint to verify the service is running.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Base"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "Healthy!"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateMessageRequest": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateTenantRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rateBurst": {
+                    "type": "integer"
+                },
+                "rateLimit": {
+                    "type": "integer"
+                },
+                "webhookUrl": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreatedAPIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CronRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "job": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "overrides": {
+                    "$ref": "#/definitions/models.RunOverrides"
+                }
+            }
+        },
+        "models.DeliveryAttempt": {
+            "type": "object",
+            "properties": {
+                "attemptNumber": {
+                    "type": "integer"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorClass": {
+                    "type": "string"
+                },
+                "httpStatus": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "idempotencyKey": {
+                    "type": "string"
+                },
+                "latencyMs": {
+                    "type": "integer"
+                },
+                "messageId": {
+                    "type": "string"
+                },
+                "requestedAt": {
+                    "type": "string"
+                },
+                "responseBody": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DriftEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "claimedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "providerMessageId": {
+                    "type": "string"
+                },
+                "sentAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EditMessageRequest": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportReport": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "description": "Errors lists the rejected rows by line number, up to a limit.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowError"
+                    }
+                },
+                "errorsTruncated": {
+                    "type": "boolean"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.JobRun": {
+            "type": "object",
+            "properties": {
+                "durationMs": {
+                    "type": "integer"
+                },
+                "endedAt": {
+                    "type": "string"
+                },
+                "errorSummary": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "fencingToken": {
+                    "type": "integer"
+                },
+                "fetched": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "instanceId": {
+                    "type": "string"
+                },
+                "jobName": {
+                    "type": "string"
+                },
+                "sent": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "boolean"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.JobStatus": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "type": "integer"
+                },
+                "inFlight": {
+                    "type": "integer"
+                },
+                "lastRun": {
+                    "type": "string"
+                },
+                "maxConcurrent": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nextRun": {
+                    "type": "string"
+                },
+                "panics": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "stuckRuns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StuckRun"
+                    }
+                },
+                "stuckTotal": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LeaderStatus": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fencingToken": {
+                    "type": "integer"
+                },
+                "instanceId": {
+                    "type": "string"
+                },
+                "isLeader": {
+                    "type": "boolean"
+                },
+                "leader": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Message": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isSent": {
+                    "type": "boolean"
+                },
+                "phoneNumber": {
+                    "type": "string"
+                },
+                "sendGeneration": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MessageDetails": {
+            "type": "object",
+            "properties": {
+                "claimedAt": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "detailsSource": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isSent": {
+                    "type": "boolean"
+                },
+                "phoneNumber": {
+                    "type": "string"
+                },
+                "providerMessageId": {
+                    "type": "string"
+                },
+                "sendGeneration": {
+                    "type": "integer"
+                },
+                "sentAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version changes on every update of the message and is exposed as its ETag.",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.MessageEvent": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "messageId": {
+                    "type": "string"
+                },
+                "phoneNumber": {
+                    "type": "string"
+                },
+                "providerMessageId": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MessageLookupRequest": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.ProviderRateStats": {
+            "type": "object",
+            "properties": {
+                "burst": {
+                    "type": "integer"
+                },
+                "currentRate": {
+                    "type": "number"
+                },
+                "distributed": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "pausedUntil": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "sentLastMinute": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReconciliationReport": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "policy": {
+                    "type": "string"
+                },
+                "postgresOnly": {
+                    "description": "PostgresOnly lists messages Postgres reports as sent with no Redis record.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DriftEntry"
+                    }
+                },
+                "redisOnly": {
+                    "description": "RedisOnly lists messages Redis reports as sent while Postgres does not.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DriftEntry"
+                    }
+                },
+                "repaired": {
+                    "type": "integer"
+                },
+                "staleClaims": {
+                    "description": "StaleClaims lists messages stuck in sending with no evidence of the provider outcome.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DriftEntry"
+                    }
+                },
+                "startedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RunOverrides": {
+            "type": "object",
+            "properties": {
+                "fetchLimit": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RuntimeSettings": {
+            "type": "object",
+            "properties": {
+                "cronInterval": {
+                    "type": "integer"
+                },
+                "maxConcurrentJobs": {
+                    "type": "integer"
+                },
+                "messageFetchLimit": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SettingAudit": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "changedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "newValue": {
+                    "type": "string"
+                },
+                "oldValue": {
+                    "type": "string"
+                },
+                "remoteAddr": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SettingsUpdate": {
+            "type": "object",
+            "properties": {
+                "cronInterval": {
+                    "type": "integer"
+                },
+                "maxConcurrentJobs": {
+                    "type": "integer"
+                },
+                "messageFetchLimit": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.StreamToken": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StuckRun": {
+            "type": "object",
+            "properties": {
+                "runId": {
+                    "type": "integer"
+                },
+                "runningForSeconds": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Tenant": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rateBurst": {
+                    "type": "integer"
+                },
+                "rateLimit": {
+                    "type": "integer"
+                },
+                "webhookUrl": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TenantConfig": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "rateBurst": {
+                    "type": "integer"
+                },
+                "rateLimit": {
+                    "type": "integer"
+                },
+                "webhookUrl": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "\"Bearer \" followed by ADMIN_TOKEN",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "ApiKey": {
+            "description": "An API key; \"Authorization: Bearer \u003ckey\u003e\" is accepted as well",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
@@ -1,27 +1,171 @@
 -- init.sql
+--
+-- Safe to run again on an existing database: tables created by an earlier
+-- version are brought up to date by the ALTER TABLE statements below.
 
 -- grant privileges to golang service user
 GRANT ALL PRIVILEGES ON DATABASE messaging_db TO sample_user;
 
+-- teams sharing the deployment; NULL provider settings fall back to the environment
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    webhook_url TEXT,
+    rate_limit INT,
+    rate_burst INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO tenants (id, name) VALUES ('default', 'Default') ON CONFLICT (id) DO NOTHING;
+
 -- create table
 CREATE TABLE IF NOT EXISTS messages (
     id VARCHAR(36) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id),
     content VARCHAR(255) NOT NULL,
     phone_number VARCHAR(20) NOT NULL,
-    is_sent BOOLEAN NOT NULL DEFAULT FALSE
-);
-
--- insert sample rows
-INSERT INTO messages (id, content, phone_number) VALUES
-    ('msg-001', 'Hello, world!', '+15551234567'),
-    ('msg-002', 'Your order has shipped.', '+15557654321'),
-    ('msg-003', 'Reminder: Your appointment is tomorrow.', '+15559876543'),
-    ('msg-004', 'Welcome to our service!', '+15552345678'),
-    ('msg-005', 'Your verification code is 4829.', '+15553456789'),
-    ('msg-006', 'Happy birthday!', '+15554567890'),
-    ('msg-007', 'Your subscription expires soon.', '+15555678901'),
-    ('msg-008', 'New login from unknown device.', '+15556789012'),
-    ('msg-009', 'Password reset request received.', '+15557890123'),
-    ('msg-010', 'Thank you for your feedback.', '+15558901234'),
-    ('msg-011', 'We have updated our terms of service.', '+15559012345'),
-    ('msg-012', 'Your invoice is ready to view.', '+15550123456');
+    is_sent BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    send_generation INT NOT NULL DEFAULT 1,
+    provider_message_id VARCHAR(64),
+    sent_at TIMESTAMPTZ,
+    claimed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    version INT NOT NULL DEFAULT 1
+);
+
+-- upgrade a messages table created before these columns existed
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id),
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    ADD COLUMN IF NOT EXISTS send_generation INT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS provider_message_id VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS sent_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- rows sent while is_sent was the only delivery state
+UPDATE messages SET status = 'sent' WHERE is_sent AND status = 'pending';
+
+-- replaced by the tenant scoped indexes below
+DROP INDEX IF EXISTS messages_created_idx;
+DROP INDEX IF EXISTS messages_phone_created_idx;
+
+CREATE INDEX IF NOT EXISTS messages_status_idx ON messages (status, id);
+CREATE INDEX IF NOT EXISTS messages_tenant_created_idx ON messages (tenant_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS messages_tenant_phone_created_idx ON messages (tenant_id, phone_number, created_at DESC, id DESC);
+
+-- trigram index backing the free-text search of the message listing
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS messages_content_trgm_idx ON messages USING gin (content gin_trgm_ops);
+
+-- sent outcomes waiting to be projected into Redis
+CREATE TABLE IF NOT EXISTS redis_outbox (
+    message_id VARCHAR(36) PRIMARY KEY REFERENCES messages (id),
+    provider_message_id VARCHAR(64),
+    idempotency_key VARCHAR(64) NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT
+);
+
+-- outbox rows queued before idempotency keys were stored reuse the first generation's key
+ALTER TABLE redis_outbox ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(64);
+UPDATE redis_outbox SET idempotency_key = message_id || ':1' WHERE idempotency_key IS NULL;
+ALTER TABLE redis_outbox ALTER COLUMN idempotency_key SET NOT NULL;
+
+-- every request made to a provider for a message
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    message_id VARCHAR(36) NOT NULL REFERENCES messages (id),
+    attempt_number INT NOT NULL,
+    endpoint TEXT NOT NULL,
+    idempotency_key VARCHAR(64) NOT NULL,
+    requested_at TIMESTAMPTZ NOT NULL,
+    latency_ms BIGINT NOT NULL,
+    http_status INT,
+    response_body TEXT,
+    error_class VARCHAR(32) NOT NULL,
+    error TEXT,
+    UNIQUE (message_id, attempt_number)
+);
+
+-- every execution of a scheduled job
+CREATE TABLE IF NOT EXISTS job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name VARCHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    fetched INT NOT NULL DEFAULT 0,
+    sent INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    skipped BOOLEAN NOT NULL DEFAULT FALSE,
+    error_summary TEXT,
+    instance_id VARCHAR(255),
+    fencing_token BIGINT NOT NULL DEFAULT 0
+);
+
+ALTER TABLE job_runs
+    ADD COLUMN IF NOT EXISTS instance_id VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS fencing_token BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS job_runs_job_started_idx ON job_runs (job_name, started_at DESC);
+CREATE INDEX IF NOT EXISTS job_runs_started_idx ON job_runs (started_at);
+
+-- highest fencing token seen per lease; claims made with an older token are rejected
+CREATE TABLE IF NOT EXISTS leader_fence (
+    name VARCHAR(64) PRIMARY KEY,
+    token BIGINT NOT NULL
+);
+
+-- runtime setting overrides made through the admin API
+CREATE TABLE IF NOT EXISTS runtime_settings (
+    key VARCHAR(64) PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_by VARCHAR(255) NOT NULL
+);
+
+-- every change of a runtime setting
+CREATE TABLE IF NOT EXISTS settings_audit (
+    id BIGSERIAL PRIMARY KEY,
+    key VARCHAR(64) NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    remote_addr VARCHAR(64),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- API keys authenticating clients; only the SHA-256 digest of a key is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id),
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+
+-- wake the send job up when messages are inserted; one notification per statement
+CREATE OR REPLACE FUNCTION notify_messages_inserted() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('messages_inserted', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS messages_inserted_notify ON messages;
+CREATE TRIGGER messages_inserted_notify
+    AFTER INSERT ON messages
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_messages_inserted();
@@ -3,15 +3,61 @@ package configs
 import (
 	"messaging-server/internal/models"
 	pkgUtils "messaging-server/pkg/utils"
+	"os"
 )
 
 // AppConfig holds the application configuration settings.
 var AppConfig = models.AppConfigStruct{
-	AppName:           pkgUtils.GetEnvStr("APP_NAME", "Messaging Server V1"),
-	LogLevel:          pkgUtils.GetEnvStr("LOG_LEVEL", "DEBUG"),
-	WebhookURL:        pkgUtils.GetEnvStr("WEBHOOK_URL", ""),
-	ServerGracePeriod: pkgUtils.GetEnvInt("SERVER_GRACE_PERIOD", 30),
-	MessageFetchLimit: pkgUtils.GetEnvInt("MESSAGE_FETCH_LIMIT", 2),
-	CronInterval:      pkgUtils.GetEnvInt("CRON_INTERVAL", 120),
-	MaxConcurrentJobs: pkgUtils.GetEnvInt("MAX_CONCURRENT_JOBS", 5),
+	AppName:                pkgUtils.GetEnvStr("APP_NAME", "Messaging Server V1"),
+	LogLevel:               pkgUtils.GetEnvStr("LOG_LEVEL", "DEBUG"),
+	WebhookURL:             pkgUtils.GetEnvStr("WEBHOOK_URL", ""),
+	ServerGracePeriod:      pkgUtils.GetEnvInt("SERVER_GRACE_PERIOD", 30),
+	MessageFetchLimit:      pkgUtils.GetEnvInt("MESSAGE_FETCH_LIMIT", 2),
+	CronInterval:           pkgUtils.GetEnvInt("CRON_INTERVAL", 120),
+	CronSchedule:           pkgUtils.GetEnvStr("CRON_SCHEDULE", ""),
+	CronTimezone:           pkgUtils.GetEnvStr("CRON_TIMEZONE", "UTC"),
+	MaxConcurrentJobs:      pkgUtils.GetEnvInt("MAX_CONCURRENT_JOBS", 5),
+	SendJobTimeout:         pkgUtils.GetEnvInt("SEND_JOB_TIMEOUT", 300),
+	ProjectJobTimeout:      pkgUtils.GetEnvInt("PROJECT_JOB_TIMEOUT", 60),
+	ReconcileJobTimeout:    pkgUtils.GetEnvInt("RECONCILE_JOB_TIMEOUT", 600),
+	PurgeJobTimeout:        pkgUtils.GetEnvInt("PURGE_JOB_TIMEOUT", 600),
+	StuckJobThreshold:      pkgUtils.GetEnvInt("STUCK_JOB_THRESHOLD", 900),
+	ProviderRateLimit:      pkgUtils.GetEnvInt("PROVIDER_RATE_LIMIT", 0),
+	ProviderRateBurst:      pkgUtils.GetEnvInt("PROVIDER_RATE_BURST", 0),
+	DistributedRateLimit:   pkgUtils.GetEnvBool("DISTRIBUTED_RATE_LIMIT", false),
+	ProjectorInterval:      pkgUtils.GetEnvInt("PROJECTOR_INTERVAL", 5),
+	ReconcileInterval:      pkgUtils.GetEnvInt("RECONCILE_INTERVAL", 600),
+	ReconcileSchedule:      pkgUtils.GetEnvStr("RECONCILE_SCHEDULE", ""),
+	ReconcilePolicy:        pkgUtils.GetEnvStr("RECONCILE_POLICY", "repair"),
+	StaleClaimAfter:        pkgUtils.GetEnvInt("STALE_CLAIM_AFTER", 600),
+	LeaderElection:         pkgUtils.GetEnvBool("LEADER_ELECTION", false),
+	LeaderLeaseTTL:         pkgUtils.GetEnvInt("LEADER_LEASE_TTL", 15),
+	InstanceID:             pkgUtils.GetEnvStr("INSTANCE_ID", hostname()),
+	AdminToken:             pkgUtils.GetEnvStr("ADMIN_TOKEN", ""),
+	APIKeyAuth:             pkgUtils.GetEnvBool("API_KEY_AUTH", true),
+	SendMode:               pkgUtils.GetEnvStr("SEND_MODE", models.SendModeBatch),
+	SendTimeBudget:         pkgUtils.GetEnvInt("SEND_TIME_BUDGET", 60),
+	BacklogAgeTarget:       pkgUtils.GetEnvInt("BACKLOG_AGE_TARGET", 300),
+	AdaptiveMaxBatch:       pkgUtils.GetEnvInt("ADAPTIVE_MAX_BATCH", 500),
+	AdaptiveLatencyTarget:  pkgUtils.GetEnvInt("ADAPTIVE_LATENCY_TARGET", 2000),
+	EventDispatch:          pkgUtils.GetEnvBool("EVENT_DISPATCH", true),
+	DispatchDebounce:       pkgUtils.GetEnvInt("DISPATCH_DEBOUNCE_MS", 200),
+	ImportMaxBytes:         int64(pkgUtils.GetEnvInt("IMPORT_MAX_BYTES", 50<<20)),
+	EventStreamLength:      int64(pkgUtils.GetEnvInt("EVENT_STREAM_LENGTH", 10000)),
+	RateLimitWindow:        pkgUtils.GetEnvInt("RATE_LIMIT_WINDOW", 60),
+	RateLimitMessagesRead:  pkgUtils.GetEnvInt("RATE_LIMIT_MESSAGES_READ", 600),
+	RateLimitMessagesWrite: pkgUtils.GetEnvInt("RATE_LIMIT_MESSAGES_WRITE", 300),
+	RateLimitCronRead:      pkgUtils.GetEnvInt("RATE_LIMIT_CRON_READ", 120),
+	RateLimitCronAdmin:     pkgUtils.GetEnvInt("RATE_LIMIT_CRON_ADMIN", 30),
+	TrustedProxies:         pkgUtils.GetEnvStr("TRUSTED_PROXIES", ""),
+	JobRunRetention:        pkgUtils.GetEnvInt("JOB_RUN_RETENTION", 7*24*3600),
+}
+
+// hostname identifies the instance when INSTANCE_ID is not set.
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
 }
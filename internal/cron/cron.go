@@ -1,92 +1,422 @@
 package cron
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"messaging-server/internal/configs"
 	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"runtime/debug"
 	"sync"
 	"time"
 )
 
-// Cron runs a job every interval, spawning the job in its own goroutine,
-// never allowing more than maxConcurrent jobs to overlap.
+// Job is the function executed by a Cron. The context is cancelled when the
+// run times out or the Cron is stopped with StopCancel; RunFromContext
+// returns the run record to report counters on.
+type Job func(ctx context.Context) error
+
+// StopMode selects how Stop treats runs that are in flight.
+type StopMode string
+
+const (
+	// StopDrain waits for in-flight runs to complete.
+	StopDrain StopMode = "drain"
+	// StopCancel cancels the context of in-flight runs and waits for them to return.
+	StopCancel StopMode = "cancel"
+)
+
+// Options tunes how a Cron executes its job.
+type Options struct {
+	// MaxConcurrent is the maximum number of overlapping runs.
+	MaxConcurrent int
+	// Timeout cancels a run after this duration; zero means no timeout.
+	Timeout time.Duration
+	// StuckAfter makes the watchdog flag runs lasting longer; zero disables it.
+	StuckAfter time.Duration
+}
+
+// Elector tells whether this instance leads the replicas and may run jobs.
+type Elector interface {
+	IsLeader() bool
+	FencingToken() int64
+	InstanceID() string
+}
+
+var (
+	// ErrMaxConcurrency is returned when a run is requested while all slots of a job are taken.
+	ErrMaxConcurrency = errors.New("max concurrency reached")
+	// ErrNotLeader is returned when a run is requested on an instance that is not the leader.
+	ErrNotLeader = errors.New("this instance is not the leader")
+	// ErrStopped is returned when a run is requested while the job is stopped or stopping.
+	ErrStopped = errors.New("job is stopped")
+)
+
+type runKey struct{}
+
+type overridesKey struct{}
+
+// inflightRun tracks a run for the watchdog.
+type inflightRun struct {
+	run   *models.JobRun
+	stuck bool
+}
+
+// RunFromContext returns the run record of the job execution owning ctx.
+// Outside of a Cron it returns a detached record, so jobs can always report on it.
+func RunFromContext(ctx context.Context) *models.JobRun {
+	if run, ok := ctx.Value(runKey{}).(*models.JobRun); ok {
+		return run
+	}
+	return &models.JobRun{Status: models.RunStatusRunning, StartedAt: time.Now()}
+}
+
+// OverridesFromContext returns the configuration overrides the run owning ctx was started with.
+func OverridesFromContext(ctx context.Context) models.RunOverrides {
+	overrides, _ := ctx.Value(overridesKey{}).(models.RunOverrides)
+	return overrides
+}
+
+// Cron runs a named job on a schedule, spawning the job in its own goroutine,
+// never allowing more than maxConcurrent jobs to overlap. The schedule and
+// the concurrency limit can be changed while the Cron is running.
 type Cron struct {
-	interval time.Duration
-	job      func()
-	wg       sync.WaitGroup
-	quit     chan struct{}
-	sem      chan struct{} // semaphore channel
-	running  bool
+	name       string
+	job        Job
+	timeout    time.Duration
+	stuckAfter time.Duration
+	elector    Elector // nil means this instance always leads
+	wg         sync.WaitGroup
+	rearm      chan struct{} // wakes the loop up after a schedule change
+
+	mu            sync.Mutex
+	schedule      Schedule
+	maxConcurrent int
+	active        int // runs holding a concurrency slot
+	quit          chan struct{}
+	running       bool
+	nextRun       time.Time
+	lastRun       time.Time
+	skipped       int
+	runCtx        context.Context // parent of every run's context
+	cancelRuns    context.CancelFunc
+	inflight      map[*models.JobRun]*inflightRun
+	failures      int
+	panics        int
+	stuck         int
 }
 
-// NewCron returns a Cron that will run job every interval.
-func NewCron(job func()) (*Cron, error) {
-	// validate the interval
-	if configs.AppConfig.CronInterval <= 0 {
-		return nil, fmt.Errorf("interval must be > 0, got %d", configs.AppConfig.CronInterval)
+// NewCron returns a Cron that will run job on schedule with at most
+// opts.MaxConcurrent overlapping runs.
+func NewCron(name string, job Job, schedule Schedule, opts Options) (*Cron, error) {
+	// validate the concurrency limit
+	if opts.MaxConcurrent <= 0 {
+		return nil, fmt.Errorf("max concurrent jobs must be > 0, got %d", opts.MaxConcurrent)
+	}
+	// validate the timeout
+	if opts.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must be >= 0, got %s", opts.Timeout)
 	}
+	runCtx, cancelRuns := context.WithCancel(context.Background())
 	return &Cron{
-		interval: time.Duration(configs.AppConfig.CronInterval) * time.Second,
-		job:      job,
-		sem:      make(chan struct{}, configs.AppConfig.MaxConcurrentJobs),
-		running:  false,
+		name:          name,
+		schedule:      schedule,
+		job:           job,
+		maxConcurrent: opts.MaxConcurrent,
+		timeout:       opts.Timeout,
+		stuckAfter:    opts.StuckAfter,
+		rearm:         make(chan struct{}, 1),
+		running:       false,
+		runCtx:        runCtx,
+		cancelRuns:    cancelRuns,
+		inflight:      map[*models.JobRun]*inflightRun{},
 	}, nil
 }
 
+// Name returns the name the job is registered under.
+func (c *Cron) Name() string {
+	return c.name
+}
+
 // Start launches the Cron loop in its own goroutine.
 func (c *Cron) Start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// check if the cron is already running
 	if c.running {
-		log.Logger.Warning("Cron is already running; cannot start again")
+		log.Logger.Warningf("Cron %s is already running; cannot start again", c.name)
 		return
 	}
 
-	c.quit = make(chan struct{})
-	ticker := time.NewTicker(c.interval)
+	quit := make(chan struct{})
+	c.quit = quit
+	c.nextRun = c.schedule.Next(time.Now())
+	timer := time.NewTimer(time.Until(c.nextRun))
 
 	c.running = true
 
 	go func() {
 		for {
 			select {
-			// wait for the ticker to tick
-			case <-ticker.C:
-				select {
-
-				// try to acquire a semaphore slot
-				// if successful, spawn the job in a goroutine
-				case c.sem <- struct{}{}:
-					c.wg.Add(1)
-					go func() {
-						defer func() {
-							<-c.sem // release the semaphore
-							c.wg.Done()
-						}()
-						c.job()
-					}()
-
-				// if no slots are available, skip this tick
-				default:
-					log.Logger.Warning("Max concurrency reached; skipping this run")
+			// wait for the next scheduled run
+			case <-timer.C:
+				// followers skip the run; if no slots are available, skip it too
+				switch err := c.Trigger(); {
+				case errors.Is(err, ErrNotLeader):
+					log.Logger.Debugf("Cron %s: not the leader; skipping this run", c.name)
+				case errors.Is(err, ErrStopped):
+					log.Logger.Debugf("Cron %s: stopping; skipping this run", c.name)
+				case err != nil:
+					log.Logger.Warningf("Cron %s: max concurrency reached; skipping this run", c.name)
 				}
+
+				// arm the timer for the following run
+				c.mu.Lock()
+				c.nextRun = c.schedule.Next(time.Now())
+				timer.Reset(time.Until(c.nextRun))
+				c.mu.Unlock()
+			// the schedule changed; re-arm the timer from now
+			case <-c.rearm:
+				c.mu.Lock()
+				c.nextRun = c.schedule.Next(time.Now())
+				timer.Reset(time.Until(c.nextRun))
+				c.mu.Unlock()
 			// check stop signal
-			case <-c.quit:
-				ticker.Stop()
+			case <-quit:
+				timer.Stop()
 				return
 			}
 		}
 	}()
 }
 
-// Stop signals the Cron to exit and waits for all jobs to complete.
-func (c *Cron) Stop() {
-	// check if the cron is already stopped
-	if !c.running {
-		log.Logger.Warning("Cron is not running; cannot stop")
+// Trigger runs the job immediately in its own goroutine, sharing the
+// semaphore with scheduled runs. It returns ErrNotLeader on a follower,
+// ErrStopped once Stop was called and ErrMaxConcurrency if no slot is free.
+func (c *Cron) Trigger() error {
+	_, err := c.RunNow(models.RunOverrides{})
+	return err
+}
+
+// RunNow is Trigger with configuration overrides for this run only. The run
+// is recorded in the job history before the job starts, so the returned run
+// ID can be polled right away; it is 0 if the history could not be written.
+func (c *Cron) RunNow(overrides models.RunOverrides) (*models.JobRun, error) {
+	if c.elector != nil && !c.elector.IsLeader() {
+		return nil, ErrNotLeader
+	}
+
+	// try to acquire a concurrency slot; Stop waits for every run admitted
+	// while the quit channel is open, so none may start after it
+	c.mu.Lock()
+	if !c.running || c.quit == nil {
+		c.mu.Unlock()
+		return nil, ErrStopped
+	}
+	if c.active >= c.maxConcurrent {
+		c.skipped++
+		c.mu.Unlock()
+		// the trigger path does not wait for the history
+		go recordSkipped(c.name)
+		return nil, ErrMaxConcurrency
+	}
+	c.active++
+	c.lastRun = time.Now()
+	c.wg.Add(1)
+	c.mu.Unlock()
+
+	// if successful, spawn the job in a goroutine
+	run := c.start()
+	go func() {
+		defer func() {
+			// release the slot
+			c.mu.Lock()
+			c.active--
+			c.mu.Unlock()
+			c.wg.Done()
+		}()
+		c.execute(run, overrides)
+	}()
+	return run, nil
+}
+
+// SetSchedule replaces the schedule; a running Cron re-arms its timer from now.
+func (c *Cron) SetSchedule(schedule Schedule) {
+	c.mu.Lock()
+	c.schedule = schedule
+	c.mu.Unlock()
+
+	select {
+	case c.rearm <- struct{}{}:
+	default: // a re-arm is already pending
+	}
+}
+
+// SetMaxConcurrent changes the concurrency limit. Lowering it does not affect
+// runs in flight; new runs wait until fewer than n are running.
+func (c *Cron) SetMaxConcurrent(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("max concurrent jobs must be > 0, got %d", n)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxConcurrent = n
+	return nil
+}
+
+// start creates the record of a new run and persists it in the job history.
+func (c *Cron) start() *models.JobRun {
+	run := &models.JobRun{JobName: c.name, Status: models.RunStatusRunning, StartedAt: time.Now()}
+	if c.elector != nil {
+		run.InstanceID = c.elector.InstanceID()
+		run.FencingToken = c.elector.FencingToken()
+	}
+	recordStart(run)
+	return run
+}
+
+// execute runs the job once and records the outcome of run in the job history.
+func (c *Cron) execute(run *models.JobRun, overrides models.RunOverrides) {
+	c.mu.Lock()
+	ctx := context.WithValue(c.runCtx, runKey{}, run)
+	c.inflight[run] = &inflightRun{run: run}
+	c.mu.Unlock()
+	ctx = context.WithValue(ctx, overridesKey{}, overrides)
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.inflight, run)
+		c.mu.Unlock()
+	}()
+
+	cancel := context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	defer cancel()
+
+	if err := c.safeRun(ctx); err != nil {
+		log.Logger.Errorf("Cron %s run %d failed: %v", c.name, run.ID, err)
+		c.mu.Lock()
+		c.failures++
+		c.mu.Unlock()
+		run.Fail(err)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			run.Status = models.RunStatusTimedOut
+		case errors.Is(err, context.Canceled):
+			run.Status = models.RunStatusCancelled
+		}
+	}
+
+	run.Finish()
+	recordFinish(run)
+}
+
+// safeRun runs the job, turning a panic into an error so it neither takes
+// down the server nor leaks the run's semaphore slot.
+func (c *Cron) safeRun(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Logger.Errorf("Cron %s panicked: %v\n%s", c.name, r, debug.Stack())
+			c.mu.Lock()
+			c.panics++
+			c.mu.Unlock()
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c.job(ctx)
+}
+
+// checkStuck flags the in-flight runs lasting longer than stuckAfter.
+func (c *Cron) checkStuck(now time.Time) {
+	if c.stuckAfter <= 0 {
 		return
 	}
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, r := range c.inflight {
+		if r.stuck || now.Sub(r.run.StartedAt) < c.stuckAfter {
+			continue
+		}
+		r.stuck = true
+		c.stuck++
+		log.Logger.Errorf("Cron %s run %d has been running since %s; flagged as stuck",
+			c.name, r.run.ID, r.run.StartedAt.Format(time.RFC3339))
+	}
+}
+
+// CancelRuns cancels the context of every in-flight run without stopping the schedule.
+func (c *Cron) CancelRuns() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cancelRuns()
+	// later runs get a fresh parent context
+	c.runCtx, c.cancelRuns = context.WithCancel(context.Background())
+}
+
+// Stop signals the Cron to exit and waits for all jobs to complete. With
+// StopCancel the in-flight runs are cancelled first instead of drained.
+func (c *Cron) Stop(mode StopMode) {
+	c.mu.Lock()
+	// check if the cron is already stopped or stopping
+	if !c.running || c.quit == nil {
+		c.mu.Unlock()
+		log.Logger.Warningf("Cron %s is not running; cannot stop", c.name)
+		return
+	}
 	close(c.quit)
+	c.quit = nil
+	c.mu.Unlock()
+
+	if mode == StopCancel {
+		c.CancelRuns()
+	}
 	c.wg.Wait()
+
+	c.mu.Lock()
 	c.running = false
+	c.nextRun = time.Time{}
+	c.mu.Unlock()
+}
+
+// Status returns a snapshot of the job's state.
+func (c *Cron) Status() models.JobStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := models.JobStatus{
+		Name:          c.name,
+		Schedule:      fmt.Sprint(c.schedule),
+		Running:       c.running,
+		InFlight:      c.active,
+		MaxConcurrent: c.maxConcurrent,
+		Skipped:       c.skipped,
+		Failures:      c.failures,
+		Panics:        c.panics,
+		StuckTotal:    c.stuck,
+		StuckRuns:     []models.StuckRun{},
+	}
+	now := time.Now()
+	for _, r := range c.inflight {
+		if r.stuck {
+			status.StuckRuns = append(status.StuckRuns, models.StuckRun{
+				RunID:      r.run.ID,
+				StartedAt:  r.run.StartedAt.Format(time.RFC3339),
+				RunningFor: int64(now.Sub(r.run.StartedAt).Seconds()),
+			})
+		}
+	}
+	if !c.nextRun.IsZero() {
+		status.NextRun = c.nextRun.Format(time.RFC3339)
+	}
+	if !c.lastRun.IsZero() {
+		status.LastRun = c.lastRun.Format(time.RFC3339)
+	}
+	return status
 }
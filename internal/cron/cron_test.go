@@ -0,0 +1,187 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// finishedRuns receives a copy of every run recorded as finished.
+var finishedRuns = make(chan models.JobRun, 64)
+
+func init() {
+	log.InitLogger()
+
+	// keep the job history in memory
+	var lastID atomic.Int64
+	insertJobRun = func(ctx context.Context, run *models.JobRun) error {
+		run.ID = lastID.Add(1)
+		return nil
+	}
+	finishJobRun = func(ctx context.Context, run *models.JobRun) error {
+		finishedRuns <- *run
+		return nil
+	}
+}
+
+// waitFinished returns the next run of job recorded as finished.
+func waitFinished(t *testing.T, job string) models.JobRun {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case run := <-finishedRuns:
+			if run.JobName == job {
+				return run
+			}
+		case <-timeout:
+			t.Fatalf("no run of %s finished", job)
+		}
+	}
+}
+
+// tick is a schedule firing every d.
+type tick time.Duration
+
+func (d tick) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// newTestCron returns a Cron running job hourly with the given options.
+func newTestCron(t *testing.T, job Job, opts Options) *Cron {
+	t.Helper()
+	schedule, err := Every(3600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCron(t.Name(), job, schedule, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRunNowRefusedWhileStopped(t *testing.T) {
+	c := newTestCron(t, func(ctx context.Context) error {
+		t.Error("a stopped job must not run")
+		return nil
+	}, Options{MaxConcurrent: 1})
+
+	if _, err := c.RunNow(models.RunOverrides{}); !errors.Is(err, ErrStopped) {
+		t.Fatalf("RunNow() before Start = %v, want ErrStopped", err)
+	}
+
+	c.Start()
+	c.Stop(StopDrain)
+	if err := c.Trigger(); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Trigger() after Stop = %v, want ErrStopped", err)
+	}
+}
+
+func TestPanickingRunFailsAndFreesItsSlot(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestCron(t, func(ctx context.Context) error {
+		if calls.Add(1) == 1 {
+			panic("boom")
+		}
+		return nil
+	}, Options{MaxConcurrent: 1})
+	c.Start()
+	defer c.Stop(StopDrain)
+
+	if _, err := c.RunNow(models.RunOverrides{}); err != nil {
+		t.Fatalf("RunNow(): %v", err)
+	}
+	run := waitFinished(t, c.Name())
+	if run.Status != models.RunStatusFailed || !strings.Contains(run.ErrorSummary, "panic: boom") {
+		t.Fatalf("panicked run recorded as %s (%q), want failed with the panic", run.Status, run.ErrorSummary)
+	}
+
+	// the slot is free again and the job keeps running
+	if _, err := c.RunNow(models.RunOverrides{}); err != nil {
+		t.Fatalf("RunNow() after the panic: %v", err)
+	}
+	if run := waitFinished(t, c.Name()); run.Status != models.RunStatusSucceeded {
+		t.Fatalf("run after the panic recorded as %s, want succeeded", run.Status)
+	}
+
+	status := c.Status()
+	if !status.Running || status.Panics != 1 || status.Failures != 1 {
+		t.Fatalf("status %+v, want running with one panic and one failure", status)
+	}
+}
+
+func TestSchedulerKeepsRunningAfterPanic(t *testing.T) {
+	var calls atomic.Int32
+	s := NewScheduler(nil)
+	err := s.Register(t.Name(), func(ctx context.Context) error {
+		if calls.Add(1) == 1 {
+			panic("boom")
+		}
+		return nil
+	}, tick(10*time.Millisecond), Options{MaxConcurrent: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	defer s.Stop(StopDrain)
+
+	if run := waitFinished(t, t.Name()); run.Status != models.RunStatusFailed {
+		t.Fatalf("first run recorded as %s, want failed", run.Status)
+	}
+	// the next scheduled run still fires
+	for {
+		if run := waitFinished(t, t.Name()); run.Status == models.RunStatusSucceeded {
+			return
+		}
+	}
+}
+
+func TestWatchdogFlagsStuckRuns(t *testing.T) {
+	release := make(chan struct{})
+	c := newTestCron(t, func(ctx context.Context) error {
+		<-release
+		return nil
+	}, Options{MaxConcurrent: 1, StuckAfter: time.Minute})
+	c.Start()
+	defer c.Stop(StopDrain)
+	defer close(release)
+
+	run, err := c.RunNow(models.RunOverrides{})
+	if err != nil {
+		t.Fatalf("RunNow(): %v", err)
+	}
+
+	// wait for the run to be in flight
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		c.mu.Lock()
+		_, ok := c.inflight[run]
+		c.mu.Unlock()
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("run never went in flight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// below the threshold nothing is flagged
+	c.checkStuck(run.StartedAt.Add(30 * time.Second))
+	if status := c.Status(); status.StuckTotal != 0 || len(status.StuckRuns) != 0 {
+		t.Fatalf("status %+v, want no stuck runs yet", status)
+	}
+
+	// past it the run is flagged once
+	c.checkStuck(run.StartedAt.Add(2 * time.Minute))
+	c.checkStuck(run.StartedAt.Add(3 * time.Minute))
+	status := c.Status()
+	if status.StuckTotal != 1 || len(status.StuckRuns) != 1 || status.StuckRuns[0].RunID != run.ID {
+		t.Fatalf("status %+v, want run %d flagged as stuck once", status, run.ID)
+	}
+}
@@ -0,0 +1,49 @@
+package cron
+
+import (
+	"context"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"time"
+)
+
+// insertJobRun and finishJobRun write the job history; tests replace them.
+var (
+	insertJobRun = func(ctx context.Context, run *models.JobRun) error {
+		return database.PostgresConnection.InsertJobRun(ctx, run)
+	}
+	finishJobRun = func(ctx context.Context, run *models.JobRun) error {
+		return database.PostgresConnection.FinishJobRun(ctx, run)
+	}
+)
+
+// recordStart persists the start of a run; history failures never block the job.
+func recordStart(run *models.JobRun) {
+	if err := insertJobRun(context.Background(), run); err != nil {
+		log.Logger.Errorf("failed to record start of %s run: %v", run.JobName, err)
+	}
+}
+
+// recordFinish persists the outcome of a run started with recordStart.
+func recordFinish(run *models.JobRun) {
+	log.Logger.Debugf("%s run %d %s in %dms: fetched=%d sent=%d failed=%d",
+		run.JobName, run.ID, run.Status, run.DurationMs, run.Fetched, run.Sent, run.Failed)
+
+	// the start could not be recorded, so there is no row to update
+	if run.ID == 0 {
+		return
+	}
+	if err := finishJobRun(context.Background(), run); err != nil {
+		log.Logger.Errorf("failed to record outcome of %s run %d: %v", run.JobName, run.ID, err)
+	}
+}
+
+// recordSkipped persists a run skipped because every slot of the job was taken.
+func recordSkipped(name string) {
+	now := time.Now()
+	run := &models.JobRun{JobName: name, Status: models.RunStatusSkipped, StartedAt: now, EndedAt: &now, Skipped: true}
+	if err := insertJobRun(context.Background(), run); err != nil {
+		log.Logger.Errorf("failed to record skipped %s run: %v", name, err)
+	}
+}
@@ -0,0 +1,108 @@
+package cron
+
+import (
+	"fmt"
+	robfig "github.com/robfig/cron/v3"
+	"time"
+)
+
+// Schedule computes the next run time of a job.
+type Schedule interface {
+	// Next returns the first run time strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// describedSchedule keeps the textual form of a schedule for status output.
+type describedSchedule struct {
+	Schedule
+	spec string
+}
+
+func (d describedSchedule) String() string {
+	return d.spec
+}
+
+// parser accepts standard 5-field expressions, an optional leading seconds
+// field and descriptors such as @hourly or @every 90s.
+var parser = robfig.NewParser(
+	robfig.SecondOptional | robfig.Minute | robfig.Hour | robfig.Dom | robfig.Month | robfig.Dow | robfig.Descriptor,
+)
+
+// zonedSchedule evaluates a cron expression on the wall clock of a fixed
+// time zone, so that e.g. "0 9 * * MON-FRI" means 09:00 local business time
+// across DST changes. A run whose wall time falls into a spring-forward gap
+// is shifted forward by the length of the gap, e.g. 02:30 fires at 03:30 when
+// clocks jump from 02:00 to 03:00, and a run in a repeated fall-back hour
+// fires only once.
+type zonedSchedule struct {
+	schedule Schedule
+	loc      *time.Location
+}
+
+func (z zonedSchedule) Next(t time.Time) time.Time {
+	local := t.In(z.loc)
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+
+	for {
+		wall = z.schedule.Next(wall)
+		if wall.IsZero() {
+			return wall
+		}
+
+		// map the wall clock time back into the zone; times inside a DST gap are shifted forward
+		next := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, z.loc)
+		if next.After(t) {
+			return next
+		}
+	}
+}
+
+// ParseSchedule parses a cron expression or descriptor evaluated in the
+// given IANA time zone. An empty time zone means UTC; a CRON_TZ= prefix in
+// spec takes precedence.
+func ParseSchedule(spec, timezone string) (Schedule, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", timezone, err)
+	}
+
+	schedule, err := parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+
+	// fixed delays such as @every do not depend on the wall clock
+	specSchedule, ok := schedule.(*robfig.SpecSchedule)
+	if !ok {
+		return describedSchedule{Schedule: schedule, spec: spec}, nil
+	}
+
+	// evaluate the fields on the wall clock, see zonedSchedule
+	if specSchedule.Location != time.Local {
+		loc = specSchedule.Location
+	}
+	specSchedule.Location = time.UTC
+
+	return describedSchedule{Schedule: zonedSchedule{schedule: specSchedule, loc: loc}, spec: spec + " (" + loc.String() + ")"}, nil
+}
+
+// Every returns a schedule firing every interval seconds.
+func Every(interval int) (Schedule, error) {
+	// validate the interval
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be > 0, got %d", interval)
+	}
+	return describedSchedule{
+		Schedule: robfig.Every(time.Duration(interval) * time.Second),
+		spec:     fmt.Sprintf("@every %ds", interval),
+	}, nil
+}
+
+// NewSchedule returns the schedule described by spec when it is set and a
+// fixed interval schedule otherwise.
+func NewSchedule(spec, timezone string, interval int) (Schedule, error) {
+	if spec != "" {
+		return ParseSchedule(spec, timezone)
+	}
+	return Every(interval)
+}
@@ -0,0 +1,95 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+func mustParse(t *testing.T, spec, timezone string) Schedule {
+	t.Helper()
+	s, err := ParseSchedule(spec, timezone)
+	if err != nil {
+		t.Fatalf("ParseSchedule(%q, %q): %v", spec, timezone, err)
+	}
+	return s
+}
+
+func TestZonedScheduleKeepsWallClockAcrossDST(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	s := mustParse(t, "0 9 * * MON-FRI", "Europe/Berlin")
+
+	// Friday before and Monday after the spring-forward change of 2026-03-29
+	fri := s.Next(time.Date(2026, 3, 27, 8, 0, 0, 0, berlin))
+	mon := s.Next(fri)
+
+	if want := time.Date(2026, 3, 27, 9, 0, 0, 0, berlin); !fri.Equal(want) {
+		t.Fatalf("first run = %s, want %s", fri, want)
+	}
+	if want := time.Date(2026, 3, 30, 9, 0, 0, 0, berlin); !mon.Equal(want) {
+		t.Fatalf("second run = %s, want %s", mon, want)
+	}
+	if fri.UTC().Hour() != 8 || mon.UTC().Hour() != 7 {
+		t.Fatalf("UTC hours = %d and %d, want 8 and 7", fri.UTC().Hour(), mon.UTC().Hour())
+	}
+}
+
+func TestZonedScheduleSpringForwardGap(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	s := mustParse(t, "30 2 * * *", "Europe/Berlin")
+
+	// 02:30 does not exist on 2026-03-29; the run fires right after the gap
+	got := s.Next(time.Date(2026, 3, 28, 12, 0, 0, 0, berlin))
+	if want := time.Date(2026, 3, 29, 3, 30, 0, 0, berlin); !got.Equal(want) {
+		t.Fatalf("run in the gap = %s, want %s", got, want)
+	}
+	got = s.Next(got)
+	if want := time.Date(2026, 3, 30, 2, 30, 0, 0, berlin); !got.Equal(want) {
+		t.Fatalf("run after the gap = %s, want %s", got, want)
+	}
+}
+
+func TestZonedScheduleFallBackRunsOnce(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	s := mustParse(t, "30 2 * * *", "Europe/Berlin")
+
+	// 02:30 happens twice on 2026-10-25
+	first := s.Next(time.Date(2026, 10, 25, 0, 0, 0, 0, berlin))
+	if local := first.In(berlin); local.Day() != 25 || local.Hour() != 2 || local.Minute() != 30 {
+		t.Fatalf("first run = %s, want 2026-10-25 02:30 local", local)
+	}
+	second := s.Next(first)
+	if want := time.Date(2026, 10, 26, 2, 30, 0, 0, berlin); !second.Equal(want) {
+		t.Fatalf("run after the repeated hour = %s, want %s", second, want)
+	}
+}
+
+func TestParseScheduleCronTZPrefix(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	s := mustParse(t, "CRON_TZ=America/New_York 0 9 * * *", "Europe/Berlin")
+
+	got := s.Next(time.Date(2026, 6, 1, 0, 0, 0, 0, ny))
+	if want := time.Date(2026, 6, 1, 9, 0, 0, 0, ny); !got.Equal(want) {
+		t.Fatalf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	if _, err := ParseSchedule("0 9 * * *", "Mars/Olympus"); err == nil {
+		t.Error("unknown time zone accepted")
+	}
+	if _, err := ParseSchedule("61 * * * *", ""); err == nil {
+		t.Error("invalid minute accepted")
+	}
+	if _, err := Every(0); err == nil {
+		t.Error("zero interval accepted")
+	}
+}
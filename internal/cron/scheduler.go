@@ -0,0 +1,149 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"messaging-server/internal/models"
+	"sync"
+	"time"
+)
+
+// watchdogInterval is how often the watchdog looks for stuck runs.
+const watchdogInterval = 10 * time.Second
+
+// Scheduler hosts many named jobs, each with its own schedule and concurrency limit.
+// Jobs only run while elector reports this instance as the leader.
+type Scheduler struct {
+	mu      sync.RWMutex
+	jobs    map[string]*Cron
+	order   []string // registration order
+	elector Elector
+}
+
+// NewScheduler returns an empty Scheduler running jobs while elector leads.
+// A nil elector makes the instance always run its jobs.
+func NewScheduler(elector Elector) *Scheduler {
+	return &Scheduler{jobs: map[string]*Cron{}, elector: elector}
+}
+
+// Register adds a job under name; names must be unique.
+func (s *Scheduler) Register(name string, job Job, schedule Schedule, opts Options) error {
+	c, err := NewCron(name, job, schedule, opts)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	c.elector = s.elector
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("job %s is already registered", name)
+	}
+	s.jobs[name] = c
+	s.order = append(s.order, name)
+	return nil
+}
+
+// Job returns the job registered under name.
+func (s *Scheduler) Job(name string) (*Cron, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.jobs[name]
+	return c, ok
+}
+
+// Jobs returns every registered job in registration order.
+func (s *Scheduler) Jobs() []*Cron {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]*Cron, 0, len(s.order))
+	for _, name := range s.order {
+		jobs = append(jobs, s.jobs[name])
+	}
+	return jobs
+}
+
+// Statuses returns the status of every registered job.
+func (s *Scheduler) Statuses() []models.JobStatus {
+	jobs := s.Jobs()
+	statuses := make([]models.JobStatus, 0, len(jobs))
+	for _, c := range jobs {
+		statuses = append(statuses, c.Status())
+	}
+	return statuses
+}
+
+// Start starts every registered job.
+func (s *Scheduler) Start() {
+	for _, c := range s.Jobs() {
+		c.Start()
+	}
+}
+
+// Stop stops every running job concurrently and waits for all of them.
+func (s *Scheduler) Stop(mode StopMode) {
+	var wg sync.WaitGroup
+	for _, c := range s.Jobs() {
+		if !c.Status().Running {
+			continue
+		}
+		wg.Add(1)
+		go func(c *Cron) {
+			defer wg.Done()
+			c.Stop(mode)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// CancelRuns cancels the in-flight runs of every job, e.g. once leadership is lost.
+func (s *Scheduler) CancelRuns() {
+	for _, c := range s.Jobs() {
+		c.CancelRuns()
+	}
+}
+
+// Shutdown stops every job and drains in-flight runs, including triggered
+// ones. When ctx expires first, the remaining runs are cancelled and
+// Shutdown waits for them to return before reporting ctx's error.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	jobs := s.Jobs()
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop(StopDrain)
+		for _, c := range jobs {
+			c.wg.Wait()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.CancelRuns()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Watch runs the stuck-run watchdog until ctx is done.
+func (s *Scheduler) Watch(ctx context.Context) {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, c := range s.Jobs() {
+				c.checkStuck(now)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
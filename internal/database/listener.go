@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"messaging-server/internal/configs"
+	log "messaging-server/internal/logging"
+	"time"
+)
+
+// InsertChannel is the channel the messages insert trigger notifies.
+const InsertChannel = "messages_inserted"
+
+// listenerPingInterval is how often an idle listener checks its connection.
+const listenerPingInterval = 90 * time.Second
+
+// ListenForInserts calls notify for every notification on InsertChannel until
+// ctx is done. The listener reconnects on its own; since notifications sent
+// while disconnected are lost, notify is also called after a reconnect.
+func ListenForInserts(ctx context.Context, notify func()) error {
+	listener := pq.NewListener(configs.PostgresConfig.ConnStr, time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			switch event {
+			case pq.ListenerEventDisconnected:
+				log.Logger.Warningf("insert listener disconnected: %v", err)
+			case pq.ListenerEventReconnected:
+				log.Logger.Info("insert listener reconnected")
+			case pq.ListenerEventConnectionAttemptFailed:
+				log.Logger.Warningf("insert listener reconnect failed: %v", err)
+			}
+		})
+	defer listener.Close()
+
+	if err := listener.Listen(InsertChannel); err != nil {
+		return err
+	}
+	log.Logger.Infof("listening for notifications on %s", InsertChannel)
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		// a nil notification means the connection was re-established
+		case <-listener.Notify:
+			notify()
+		case <-ticker.C:
+			go func() {
+				if err := listener.Ping(); err != nil {
+					log.Logger.Warningf("insert listener ping failed: %v", err)
+				}
+			}()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
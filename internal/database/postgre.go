@@ -1,39 +1,233 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"messaging-server/internal/configs"
 	log "messaging-server/internal/logging"
 	"messaging-server/internal/models"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+var (
+	// ErrMessageNotFound is returned when no message has the given ID.
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrMessageNotPending is returned when a message was already claimed, sent or cancelled.
+	ErrMessageNotPending = errors.New("message is no longer pending")
+	// ErrVersionMismatch is returned when a message changed since the given version.
+	ErrVersionMismatch = errors.New("message version mismatch")
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// PostgresDB runs the queries of the service on a connection pool that is
+// replaced when the connection is lost.
 type PostgresDB struct {
-	*sql.DB
+	db atomic.Pointer[sql.DB]
 }
 
 var PostgresConnection *PostgresDB
 
-const fetchQuery = `
-        SELECT id, content, phone_number, is_sent
-        FROM messages
-        WHERE is_sent = FALSE
-        ORDER BY id	
-        LIMIT $1
+// reconnectMu serializes replacing the connection pool of PostgresConnection.
+var reconnectMu sync.Mutex
+
+const claimQuery = `
+        UPDATE messages
+           SET status = 'sending', claimed_at = now(), version = version + 1
+         WHERE id IN (
+                SELECT id
+                  FROM messages
+                 WHERE status = 'pending'
+                 ORDER BY id
+                 LIMIT $1
+                   FOR UPDATE SKIP LOCKED)
+        RETURNING id, tenant_id, content, phone_number, is_sent, status, send_generation
+    `
+
+// fencedClaimQuery claims like claimQuery, but only while $2 is not older than
+// the newest fencing token seen for the scheduler lease, so a leader that lost
+// its lease can not claim messages anymore.
+const fencedClaimQuery = `
+        WITH fence AS (
+                INSERT INTO leader_fence (name, token)
+                VALUES ('scheduler', $2)
+                    ON CONFLICT (name) DO UPDATE
+                   SET token = EXCLUDED.token
+                 WHERE leader_fence.token <= EXCLUDED.token
+             RETURNING token)
+        UPDATE messages
+           SET status = 'sending', claimed_at = now(), version = version + 1
+         WHERE EXISTS (SELECT 1 FROM fence)
+           AND id IN (
+                SELECT id
+                  FROM messages
+                 WHERE status = 'pending'
+                 ORDER BY id
+                 LIMIT $1
+                   FOR UPDATE SKIP LOCKED)
+        RETURNING id, tenant_id, content, phone_number, is_sent, status, send_generation
     `
 
-const fetchAllSentQuery = `
-    SELECT id, content, phone_number, is_sent
-  	FROM messages
-    WHERE is_sent = TRUE
-  	ORDER BY id
-`
+const oldestPendingAgeQuery = `
+        SELECT COALESCE(EXTRACT(EPOCH FROM now() - MIN(created_at)), 0)
+          FROM messages
+         WHERE status = 'pending'
+    `
+
+const insertMessageQuery = `
+        INSERT INTO messages (id, tenant_id, content, phone_number)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, tenant_id, content, phone_number, is_sent, status, send_generation, created_at
+    `
+
+const messageDetailsColumns = `
+        id, tenant_id, content, phone_number, status, is_sent, send_generation, created_at, claimed_at,
+        COALESCE(provider_message_id, ''), sent_at, version
+    `
+
+const fetchMessageDetailsQuery = `SELECT ` + messageDetailsColumns + ` FROM messages WHERE id = ANY($1) AND tenant_id = $2`
+
+// editPendingQuery changes a pending message; a new content is a deliberate
+// new send, so it gets a new idempotency key. A message the provider may
+// already have accepted keeps its key, so the provider can still drop the
+// duplicate. $4 = 0 skips the version check.
+const editPendingQuery = `
+        UPDATE messages
+           SET content = COALESCE($2, content), phone_number = COALESCE($3, phone_number),
+               send_generation = send_generation + CASE WHEN EXISTS (
+                   SELECT 1 FROM delivery_attempts a
+                    WHERE a.message_id = messages.id
+                      AND a.error_class IN ('timeout', 'network', 'server_error', 'unexpected_status')
+               ) THEN 0 ELSE 1 END,
+               version = version + 1
+         WHERE id = $1 AND tenant_id = $5 AND status = 'pending' AND ($4 = 0 OR version = $4)
+     RETURNING ` + messageDetailsColumns
+
+// cancelPendingQuery cancels a pending message. $2 = 0 skips the version check.
+const cancelPendingQuery = `
+        UPDATE messages
+           SET status = 'cancelled', version = version + 1
+         WHERE id = $1 AND tenant_id = $3 AND status = 'pending' AND ($2 = 0 OR version = $2)
+     RETURNING ` + messageDetailsColumns
+
+const messageStateQuery = `
+        SELECT status, version FROM messages WHERE id = $1 AND tenant_id = $2
+    `
+
+const releaseQuery = `
+        UPDATE messages
+           SET status = 'pending', claimed_at = NULL, version = version + 1
+         WHERE id = $1 AND status = 'sending'
+    `
+
+const messageColumns = `
+        id, tenant_id, content, phone_number, is_sent, status, send_generation, created_at
+    `
 
 const updateQuery = `
         UPDATE messages
-           SET is_sent = TRUE
-         WHERE id = $1
+           SET is_sent = TRUE, status = 'sent', provider_message_id = $2, sent_at = $3, version = version + 1
+         WHERE id = $1 AND status = 'sending'
+    `
+
+const insertOutboxQuery = `
+        INSERT INTO redis_outbox (message_id, provider_message_id, sent_at, idempotency_key)
+        VALUES ($1, $2, $3, $4)
+    `
+
+const fetchOutboxQuery = `
+        SELECT message_id, COALESCE(provider_message_id, ''), sent_at, idempotency_key
+          FROM redis_outbox
+         WHERE next_attempt_at <= now()
+         ORDER BY next_attempt_at
+         LIMIT $1
+    `
+
+const deleteOutboxQuery = `
+        DELETE FROM redis_outbox
+         WHERE message_id = $1
+    `
+
+const deferOutboxQuery = `
+        UPDATE redis_outbox
+           SET attempts = attempts + 1,
+               last_error = $2,
+               next_attempt_at = now() + LEAST(power(2, attempts), 300) * interval '1 second'
+         WHERE message_id = $1
+    `
+
+const fetchUnsentByIDsQuery = `
+        SELECT id, status
+          FROM messages
+         WHERE id = ANY($1) AND status <> 'sent'
+    `
+
+const fetchUnprojectedSentQuery = `
+        SELECT m.id, COALESCE(m.provider_message_id, ''), m.sent_at, m.id || ':' || m.send_generation
+          FROM messages m
+         WHERE m.status = 'sent'
+           AND m.sent_at > now() - $1 * interval '1 second'
+           AND NOT EXISTS (SELECT 1 FROM redis_outbox o WHERE o.message_id = m.id)
+    `
+
+const fetchStaleClaimsQuery = `
+        SELECT id, claimed_at
+          FROM messages
+         WHERE status = 'sending' AND claimed_at < now() - $1 * interval '1 second'
+         ORDER BY claimed_at
+    `
+
+// releaseStaleQuery releases a stale claim the provider never accepted, keeping
+// its send generation so a retry reuses the idempotency key of earlier attempts.
+const releaseStaleQuery = `
+        UPDATE messages
+           SET status = 'pending', claimed_at = NULL, version = version + 1
+         WHERE id = $1 AND status = 'sending' AND claimed_at < now() - $2 * interval '1 second'
+           AND NOT EXISTS (
+               SELECT 1 FROM delivery_attempts a WHERE a.message_id = messages.id AND a.error_class = 'none'
+           )
+    `
+
+const reconcileSentQuery = `
+        UPDATE messages
+           SET is_sent = TRUE, status = 'sent', provider_message_id = $2, sent_at = COALESCE($3::timestamptz, now()),
+               version = version + 1
+         WHERE id = $1 AND status IN ('pending', 'sending')
+    `
+
+const requeueOutboxQuery = `
+        INSERT INTO redis_outbox (message_id, provider_message_id, sent_at, idempotency_key)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (message_id) DO NOTHING
+    `
+
+const insertAttemptQuery = `
+        INSERT INTO delivery_attempts (message_id, attempt_number, endpoint, idempotency_key, requested_at,
+                                       latency_ms, http_status, response_body, error_class, error)
+        SELECT $1, COALESCE(MAX(attempt_number), 0) + 1, $2, $3, $4, $5, $6, $7, $8, $9
+          FROM delivery_attempts
+         WHERE message_id = $1
+        RETURNING id, attempt_number
+    `
+
+const fetchAttemptsQuery = `
+        SELECT a.id, a.message_id, a.attempt_number, a.endpoint, a.idempotency_key, a.requested_at,
+               a.latency_ms, COALESCE(a.http_status, 0), COALESCE(a.response_body, ''), a.error_class, COALESCE(a.error, '')
+          FROM delivery_attempts a
+          JOIN messages m ON m.id = a.message_id
+         WHERE a.message_id = $1 AND m.tenant_id = $2
+         ORDER BY a.attempt_number
+    `
+
+const messageExistsQuery = `
+        SELECT EXISTS (SELECT 1 FROM messages WHERE id = $1 AND tenant_id = $2)
     `
 
 // ConnectPostgres initializes DB on first call; returns an error if it fails.
@@ -43,44 +237,79 @@ func ConnectPostgres() error {
 		return nil
 	}
 
+	db, err := openPostgres()
+	if err != nil {
+		return err
+	}
+
+	p := &PostgresDB{}
+	p.db.Store(db)
+	PostgresConnection = p
+	log.Logger.Info("Postgres connection established")
+	return nil
+}
+
+// openPostgres opens a connection pool with the configured settings and pings it.
+func openPostgres() (*sql.DB, error) {
 	db, err := sql.Open("postgres", configs.PostgresConfig.ConnStr)
 	if err != nil {
-		return fmt.Errorf("failed to open postgres: %w", err)
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
 	}
 	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping postgres: %w", err)
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to ping postgres: %w", err)
 	}
+	return db, nil
+}
 
-	PostgresConnection = &PostgresDB{DB: db}
-	log.Logger.Info("Postgres connection established")
-	return nil
+// conn returns the current connection pool.
+func (p *PostgresDB) conn() *sql.DB {
+	return p.db.Load()
 }
 
-// ensureConnection pings the DB and, if it has dropped, tears it down
-// and re-runs ConnectPostgres to rebuild the singleton.
+// ensureConnection pings the DB and, if it has dropped, replaces the pool with
+// a fresh one. Concurrent callers seeing the same dropped pool reconnect once.
 func (p *PostgresDB) ensureConnection() {
-	if err := p.Ping(); err != nil {
-		log.Logger.Warningf("lost DB connection (%v), reconnecting…", err)
-		_ = p.Close()
-
-		// clear DB so ConnectPostgres will re-init
-		PostgresConnection = nil
-		if err := ConnectPostgres(); err != nil {
-			log.Logger.Fatalf("reconnect failed: %v", err)
-		}
-		// reset receiver to the fresh global
-		*p = *PostgresConnection
+	db := p.conn()
+	err := db.Ping()
+	if err == nil {
+		return
 	}
+
+	reconnectMu.Lock()
+	defer reconnectMu.Unlock()
+	// another caller already replaced the pool
+	if p.conn() != db {
+		return
+	}
+
+	log.Logger.Warningf("lost DB connection (%v), reconnecting…", err)
+	fresh, err := openPostgres()
+	if err != nil {
+		log.Logger.Fatalf("reconnect failed: %v", err)
+	}
+	p.db.Store(fresh)
+	_ = db.Close()
 }
 
-func (p *PostgresDB) FetchPendingMessages(limit int) ([]models.Message, error) {
+// ClaimPendingMessages atomically moves up to limit pending messages to the
+// sending status and returns them. Rows claimed by a concurrent job are skipped.
+// A positive fencing token makes the claim fail silently, returning no
+// messages, once a newer leader has claimed with a higher token.
+func (p *PostgresDB) ClaimPendingMessages(ctx context.Context, limit int, fencingToken int64) ([]models.Message, error) {
 
 	p.ensureConnection()
 
 	// execute the query
-	rows, err := p.Query(fetchQuery, limit)
+	var rows *sql.Rows
+	var err error
+	if fencingToken > 0 {
+		rows, err = p.conn().QueryContext(ctx, fencedClaimQuery, limit, fencingToken)
+	} else {
+		rows, err = p.conn().QueryContext(ctx, claimQuery, limit)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("query pending messages: %w", err)
+		return nil, fmt.Errorf("claim pending messages: %w", err)
 	}
 	defer rows.Close()
 
@@ -89,7 +318,7 @@ func (p *PostgresDB) FetchPendingMessages(limit int) ([]models.Message, error) {
 	// iterate over the rows
 	for rows.Next() {
 		var m models.Message
-		if err := rows.Scan(&m.ID, &m.Content, &m.PhoneNumber, &m.IsSent); err != nil {
+		if err := rows.Scan(&m.ID, &m.TenantID, &m.Content, &m.PhoneNumber, &m.IsSent, &m.Status, &m.SendGeneration); err != nil {
 			return nil, fmt.Errorf("scan message: %w", err)
 		}
 		msgs = append(msgs, m)
@@ -98,52 +327,527 @@ func (p *PostgresDB) FetchPendingMessages(limit int) ([]models.Message, error) {
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
 
-	log.Logger.Debug("Messages fetched from DB")
+	log.Logger.Debug("Messages claimed from DB")
 	return msgs, nil
 }
 
-func (p *PostgresDB) MarkSent(id string) error {
+// InsertMessage enqueues a pending message of the tenant and returns the stored row.
+func (p *PostgresDB) InsertMessage(ctx context.Context, tenantID, id, content, phoneNumber string) (models.Message, error) {
+
+	p.ensureConnection()
+
+	var m models.Message
+	err := p.conn().QueryRowContext(ctx, insertMessageQuery, id, tenantID, content, phoneNumber).
+		Scan(&m.ID, &m.TenantID, &m.Content, &m.PhoneNumber, &m.IsSent, &m.Status, &m.SendGeneration, &m.CreatedAt)
+	if err != nil {
+		return m, fmt.Errorf("inserting message: %w", err)
+	}
+	return m, nil
+}
+
+// OldestPendingAge returns how long the oldest pending message has been waiting.
+func (p *PostgresDB) OldestPendingAge(ctx context.Context) (time.Duration, error) {
+
+	p.ensureConnection()
+
+	var seconds float64
+	if err := p.conn().QueryRowContext(ctx, oldestPendingAgeQuery).Scan(&seconds); err != nil {
+		return 0, fmt.Errorf("oldest pending age: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ReleaseMessage puts a claimed message back to pending so a later run retries it.
+func (p *PostgresDB) ReleaseMessage(ctx context.Context, id string) error {
 
 	p.ensureConnection()
 
+	if _, err := p.conn().ExecContext(ctx, releaseQuery, id); err != nil {
+		return fmt.Errorf("releasing message %s: %w", id, err)
+	}
+
+	log.Logger.Debugf("Message %s released back to pending", id)
+	return nil
+}
+
+// MarkSent stores the provider outcome of a claimed message and queues its
+// Redis projection in a single transaction.
+func (p *PostgresDB) MarkSent(ctx context.Context, rec models.RedisRecord) error {
+
+	p.ensureConnection()
+
+	tx, err := p.conn().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// execute the update query
-	res, err := p.Exec(updateQuery, id)
+	res, err := tx.ExecContext(ctx, updateQuery, rec.ID, nullable(rec.MessageID), rec.SentAt)
 	if err != nil {
-		return fmt.Errorf("updating is_sent for id %s: %w", id, err)
+		return fmt.Errorf("updating is_sent for id %s: %w", rec.ID, err)
 	}
 
 	// check if any rows were affected
 	rows, _ := res.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("no message found with id %s", id)
+		return fmt.Errorf("no claimed message found with id %s", rec.ID)
 	}
 
-	log.Logger.Debugf("Message %s marked as sent", id)
+	// queue the Redis projection
+	if _, err := tx.ExecContext(ctx, insertOutboxQuery, rec.ID, nullable(rec.MessageID), rec.SentAt, rec.IdempotencyKey); err != nil {
+		return fmt.Errorf("queueing redis projection for id %s: %w", rec.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit sent outcome for id %s: %w", rec.ID, err)
+	}
+
+	log.Logger.Debugf("Message %s marked as sent", rec.ID)
 	return nil
 }
 
-// FetchAllSentMessages retrieves *all* messages that have been sent.
-func (p *PostgresDB) FetchAllSentMessages() ([]models.Message, error) {
+// FetchOutbox returns up to limit sent outcomes that are due for projection into Redis.
+func (p *PostgresDB) FetchOutbox(ctx context.Context, limit int) ([]models.RedisRecord, error) {
+
 	p.ensureConnection()
 
-	rows, err := p.Query(fetchAllSentQuery)
+	rows, err := p.conn().QueryContext(ctx, fetchOutboxQuery, limit)
 	if err != nil {
-		return nil, fmt.Errorf("query all pending messages: %w", err)
+		return nil, fmt.Errorf("query redis outbox: %w", err)
 	}
 	defer rows.Close()
 
-	var msgs []models.Message
+	var recs []models.RedisRecord
+	for rows.Next() {
+		var rec models.RedisRecord
+		var sentAt time.Time
+		if err := rows.Scan(&rec.ID, &rec.MessageID, &sentAt, &rec.IdempotencyKey); err != nil {
+			return nil, fmt.Errorf("scan outbox record: %w", err)
+		}
+		rec.SentAt = sentAt.Format(time.RFC3339)
+		recs = append(recs, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return recs, nil
+}
+
+// DeleteOutbox removes a projected record from the outbox.
+func (p *PostgresDB) DeleteOutbox(ctx context.Context, id string) error {
+
+	p.ensureConnection()
+
+	if _, err := p.conn().ExecContext(ctx, deleteOutboxQuery, id); err != nil {
+		return fmt.Errorf("deleting outbox record %s: %w", id, err)
+	}
+	return nil
+}
+
+// DeferOutbox records a failed projection and backs off its next attempt.
+func (p *PostgresDB) DeferOutbox(ctx context.Context, id string, cause error) error {
+
+	p.ensureConnection()
+
+	if _, err := p.conn().ExecContext(ctx, deferOutboxQuery, id, cause.Error()); err != nil {
+		return fmt.Errorf("deferring outbox record %s: %w", id, err)
+	}
+	return nil
+}
+
+// nullable maps an empty string to SQL NULL.
+func nullable(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// exportFetchSize is the number of rows fetched from the export cursor at once.
+const exportFetchSize = 1000
+
+// messageFilterWhere builds the WHERE clause selecting the messages of filter.
+func messageFilterWhere(filter models.MessageFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, vals ...any) {
+		placeholders := make([]any, len(vals))
+		for i, v := range vals {
+			args = append(args, v)
+			placeholders[i] = len(args)
+		}
+		conds = append(conds, fmt.Sprintf(cond, placeholders...))
+	}
+	if filter.TenantID != "" {
+		add("tenant_id = $%d", filter.TenantID)
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.PhoneNumber != "" {
+		add("phone_number = $%d", filter.PhoneNumber)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+	if filter.Query != "" {
+		add(`content ILIKE $%d ESCAPE '\'`, "%"+likeEscaper.Replace(filter.Query)+"%")
+	}
+	if filter.After != nil {
+		add("(created_at, id) < ($%d, $%d)", filter.After.CreatedAt, filter.After.ID)
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ExportMessages passes every message matching filter, oldest first, to emit
+// in batches. The rows are read through a server-side cursor on a single
+// snapshot, so neither side holds the whole result in memory and the export
+// is consistent even while messages change. Returning an error from emit stops the export.
+func (p *PostgresDB) ExportMessages(ctx context.Context, filter models.MessageFilter, emit func([]models.MessageDetails) error) error {
+	p.ensureConnection()
+
+	tx, err := p.conn().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	where, args := messageFilterWhere(filter)
+	declare := "DECLARE export_cursor NO SCROLL CURSOR FOR SELECT " + messageDetailsColumns +
+		" FROM messages" + where + " ORDER BY created_at, id"
+	if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+		return fmt.Errorf("declare export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM export_cursor", exportFetchSize)
+	batch := make([]models.MessageDetails, 0, exportFetchSize)
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("fetch export cursor: %w", err)
+		}
+		batch = batch[:0]
+		for rows.Next() {
+			d, err := scanMessageDetails(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, d)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("rows error: %w", err)
+		}
+		rows.Close()
+
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := emit(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// FetchMessages returns a page of messages matching filter, newest first,
+// and the cursor of the next page, which is nil on the last page.
+func (p *PostgresDB) FetchMessages(ctx context.Context, filter models.MessageFilter) ([]models.Message, *models.MessageCursor, error) {
+	p.ensureConnection()
+
+	where, args := messageFilterWhere(filter)
+
+	// fetch one more row to know whether another page follows
+	query := fmt.Sprintf("SELECT %s FROM messages%s ORDER BY created_at DESC, id DESC LIMIT $%d",
+		messageColumns, where, len(args)+1)
+	rows, err := p.conn().QueryContext(ctx, query, append(args, filter.Limit+1)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query messages: %w", err)
+	}
+	defer rows.Close()
+
+	msgs := []models.Message{}
 	for rows.Next() {
 		var m models.Message
-		if err := rows.Scan(&m.ID, &m.Content, &m.PhoneNumber, &m.IsSent); err != nil {
-			return nil, fmt.Errorf("scan message: %w", err)
+		if err := rows.Scan(&m.ID, &m.TenantID, &m.Content, &m.PhoneNumber, &m.IsSent, &m.Status, &m.SendGeneration, &m.CreatedAt); err != nil {
+			return nil, nil, fmt.Errorf("scan message: %w", err)
 		}
 		msgs = append(msgs, m)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(msgs) <= filter.Limit {
+		return msgs, nil, nil
+	}
+	msgs = msgs[:filter.Limit]
+	last := msgs[len(msgs)-1]
+	return msgs, &models.MessageCursor{CreatedAt: last.CreatedAt, ID: last.ID}, nil
+}
+
+// FetchMessageDetails returns the given messages of the tenant with the delivery
+// details stored in Postgres, keyed by ID. Unknown IDs and the messages of other
+// tenants are left out.
+func (p *PostgresDB) FetchMessageDetails(ctx context.Context, tenantID string, ids []string) (map[string]models.MessageDetails, error) {
+	p.ensureConnection()
+
+	rows, err := p.conn().QueryContext(ctx, fetchMessageDetailsQuery, pq.Array(ids), tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query message details: %w", err)
+	}
+	defer rows.Close()
+
+	details := make(map[string]models.MessageDetails, len(ids))
+	for rows.Next() {
+		d, err := scanMessageDetails(rows)
+		if err != nil {
+			return nil, err
+		}
+		details[d.ID] = d
+	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
+	return details, nil
+}
 
-	log.Logger.Debugf("Fetched %d unsent messages", len(msgs))
-	return msgs, nil
+// EditPendingMessage changes the content and/or phone number of a pending
+// message of the tenant. A positive version must match the stored one. It
+// returns ErrMessageNotFound, ErrMessageNotPending or ErrVersionMismatch when
+// the message can not be edited.
+func (p *PostgresDB) EditPendingMessage(ctx context.Context, tenantID, id string, content, phoneNumber *string, version int) (models.MessageDetails, error) {
+	p.ensureConnection()
+
+	d, err := scanMessageDetails(p.conn().QueryRowContext(ctx, editPendingQuery, id, content, phoneNumber, version, tenantID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, p.pendingConflict(ctx, tenantID, id)
+	}
+	return d, err
+}
+
+// CancelPendingMessage cancels a pending message of the tenant so it is never
+// sent. A positive version must match the stored one. It returns the same
+// errors as EditPendingMessage.
+func (p *PostgresDB) CancelPendingMessage(ctx context.Context, tenantID, id string, version int) (models.MessageDetails, error) {
+	p.ensureConnection()
+
+	d, err := scanMessageDetails(p.conn().QueryRowContext(ctx, cancelPendingQuery, id, version, tenantID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, p.pendingConflict(ctx, tenantID, id)
+	}
+	return d, err
+}
+
+// pendingConflict tells why a conditional update of a pending message matched no row.
+func (p *PostgresDB) pendingConflict(ctx context.Context, tenantID, id string) error {
+	var status string
+	var version int
+	err := p.conn().QueryRowContext(ctx, messageStateQuery, id, tenantID).Scan(&status, &version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrMessageNotFound
+	case err != nil:
+		return fmt.Errorf("checking message %s: %w", id, err)
+	case status != models.StatusPending:
+		return fmt.Errorf("%w: message is %s", ErrMessageNotPending, status)
+	default:
+		return fmt.Errorf("%w: current version is %d", ErrVersionMismatch, version)
+	}
+}
+
+// scanMessageDetails scans a row selected with messageDetailsColumns.
+func scanMessageDetails(row interface{ Scan(...any) error }) (models.MessageDetails, error) {
+	var d models.MessageDetails
+	var sentAt sql.NullTime
+	if err := row.Scan(&d.ID, &d.TenantID, &d.Content, &d.PhoneNumber, &d.Status, &d.IsSent, &d.SendGeneration,
+		&d.CreatedAt, &d.ClaimedAt, &d.ProviderMessageID, &sentAt, &d.Version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return d, err
+		}
+		return d, fmt.Errorf("scan message details: %w", err)
+	}
+	if sentAt.Valid {
+		d.SentAt = sentAt.Time.Format(time.RFC3339)
+	}
+	return d, nil
+}
+
+// FetchUnsentByIDs returns the status of those given messages that are not sent, keyed by ID.
+func (p *PostgresDB) FetchUnsentByIDs(ctx context.Context, ids []string) (map[string]string, error) {
+	p.ensureConnection()
+
+	rows, err := p.conn().QueryContext(ctx, fetchUnsentByIDsQuery, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("query unsent messages: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := map[string]string{}
+	for rows.Next() {
+		var id, status string
+		if err := rows.Scan(&id, &status); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		statuses[id] = status
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return statuses, nil
+}
+
+// FetchUnprojectedSent returns messages sent within the last window seconds
+// that have no pending Redis projection, i.e. should be present in Redis.
+func (p *PostgresDB) FetchUnprojectedSent(ctx context.Context, window int) ([]models.RedisRecord, error) {
+	p.ensureConnection()
+
+	rows, err := p.conn().QueryContext(ctx, fetchUnprojectedSentQuery, window)
+	if err != nil {
+		return nil, fmt.Errorf("query sent messages: %w", err)
+	}
+	defer rows.Close()
+
+	var recs []models.RedisRecord
+	for rows.Next() {
+		var rec models.RedisRecord
+		var sentAt time.Time
+		if err := rows.Scan(&rec.ID, &rec.MessageID, &sentAt, &rec.IdempotencyKey); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		rec.SentAt = sentAt.Format(time.RFC3339)
+		recs = append(recs, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return recs, nil
+}
+
+// FetchStaleClaims returns messages claimed for sending more than age seconds ago.
+func (p *PostgresDB) FetchStaleClaims(ctx context.Context, age int) ([]models.DriftEntry, error) {
+	p.ensureConnection()
+
+	rows, err := p.conn().QueryContext(ctx, fetchStaleClaimsQuery, age)
+	if err != nil {
+		return nil, fmt.Errorf("query stale claims: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.DriftEntry
+	for rows.Next() {
+		var claimedAt time.Time
+		e := models.DriftEntry{Status: models.StatusSending}
+		if err := rows.Scan(&e.ID, &claimedAt); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		e.ClaimedAt = claimedAt.Format(time.RFC3339)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ReleaseStaleClaim puts a message claimed more than age seconds ago back to
+// pending unless a delivery attempt of it succeeded. It reports whether the
+// message was released.
+func (p *PostgresDB) ReleaseStaleClaim(ctx context.Context, id string, age int) (bool, error) {
+	p.ensureConnection()
+
+	res, err := p.conn().ExecContext(ctx, releaseStaleQuery, id, age)
+	if err != nil {
+		return false, fmt.Errorf("releasing stale claim of message %s: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ReconcileSent marks a pending or sending message as sent from a Redis
+// record without queueing a new projection. It reports whether the message
+// was marked; messages in any other state, e.g. cancelled, are left alone.
+func (p *PostgresDB) ReconcileSent(ctx context.Context, rec models.RedisRecord) (bool, error) {
+	p.ensureConnection()
+
+	res, err := p.conn().ExecContext(ctx, reconcileSentQuery, rec.ID, nullable(rec.MessageID), nullable(rec.SentAt))
+	if err != nil {
+		return false, fmt.Errorf("reconciling message %s: %w", rec.ID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
+// RequeueOutbox queues the Redis projection of a sent message again.
+func (p *PostgresDB) RequeueOutbox(ctx context.Context, rec models.RedisRecord) error {
+	p.ensureConnection()
+
+	if _, err := p.conn().ExecContext(ctx, requeueOutboxQuery, rec.ID, nullable(rec.MessageID), rec.SentAt, rec.IdempotencyKey); err != nil {
+		return fmt.Errorf("requeueing projection of message %s: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// InsertDeliveryAttempt stores a delivery attempt, numbering it after the previous attempts of its message.
+func (p *PostgresDB) InsertDeliveryAttempt(ctx context.Context, a *models.DeliveryAttempt) error {
+	p.ensureConnection()
+
+	status := sql.NullInt64{Int64: int64(a.HTTPStatus), Valid: a.HTTPStatus != 0}
+	err := p.conn().QueryRowContext(ctx, insertAttemptQuery, a.MessageID, a.Endpoint, a.IdempotencyKey, a.RequestedAt,
+		a.LatencyMs, status, nullable(a.ResponseBody), a.ErrorClass, nullable(a.Error)).Scan(&a.ID, &a.AttemptNumber)
+	if err != nil {
+		return fmt.Errorf("inserting delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// FetchDeliveryAttempts returns every delivery attempt of a message of the tenant in attempt order.
+func (p *PostgresDB) FetchDeliveryAttempts(ctx context.Context, tenantID, id string) ([]models.DeliveryAttempt, error) {
+	p.ensureConnection()
+
+	rows, err := p.conn().QueryContext(ctx, fetchAttemptsQuery, id, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query delivery attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []models.DeliveryAttempt{}
+	for rows.Next() {
+		var a models.DeliveryAttempt
+		if err := rows.Scan(&a.ID, &a.MessageID, &a.AttemptNumber, &a.Endpoint, &a.IdempotencyKey, &a.RequestedAt,
+			&a.LatencyMs, &a.HTTPStatus, &a.ResponseBody, &a.ErrorClass, &a.Error); err != nil {
+			return nil, fmt.Errorf("scan delivery attempt: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// MessageExists reports whether the tenant has a message with the given ID.
+func (p *PostgresDB) MessageExists(ctx context.Context, tenantID, id string) (bool, error) {
+	p.ensureConnection()
+
+	var exists bool
+	if err := p.conn().QueryRowContext(ctx, messageExistsQuery, id, tenantID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("checking message %s: %w", id, err)
+	}
+	return exists, nil
 }
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"messaging-server/internal/models"
+)
+
+// ErrAPIKeyNotFound is returned when no active API key matches.
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+const apiKeyColumns = `id, name, tenant_id, prefix, scopes, created_by, created_at, last_used_at, revoked_at`
+
+const insertAPIKeyQuery = `
+        INSERT INTO api_keys (name, tenant_id, prefix, key_hash, scopes, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING ` + apiKeyColumns
+
+const fetchActiveAPIKeyQuery = `
+        SELECT ` + apiKeyColumns + `
+          FROM api_keys
+         WHERE key_hash = $1 AND revoked_at IS NULL
+    `
+
+const fetchAPIKeysQuery = `
+        SELECT ` + apiKeyColumns + `
+          FROM api_keys
+         ORDER BY id
+    `
+
+const revokeAPIKeyQuery = `
+        UPDATE api_keys SET revoked_at = now()
+         WHERE id = $1 AND revoked_at IS NULL
+        RETURNING ` + apiKeyColumns
+
+// touching a key at most once a minute keeps last-used tracking off the hot path
+const touchAPIKeyQuery = `
+        UPDATE api_keys SET last_used_at = now()
+         WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+    `
+
+// InsertAPIKey stores a new key of the tenant by the digest of its secret and
+// returns it. ErrTenantNotFound is returned when the tenant does not exist.
+func (p *PostgresDB) InsertAPIKey(ctx context.Context, name, tenantID, prefix, keyHash string, scopes []string, createdBy string) (models.APIKey, error) {
+	p.ensureConnection()
+
+	key, err := scanAPIKey(p.conn().QueryRowContext(ctx, insertAPIKeyQuery, name, tenantID, prefix, keyHash, pq.Array(scopes), createdBy))
+	if isForeignKeyViolation(err) {
+		return key, ErrTenantNotFound
+	}
+	if err != nil {
+		return key, fmt.Errorf("insert API key: %w", err)
+	}
+	return key, nil
+}
+
+// FetchActiveAPIKey returns the unrevoked key with the given digest, or ErrAPIKeyNotFound.
+func (p *PostgresDB) FetchActiveAPIKey(ctx context.Context, keyHash string) (models.APIKey, error) {
+	p.ensureConnection()
+
+	key, err := scanAPIKey(p.conn().QueryRowContext(ctx, fetchActiveAPIKeyQuery, keyHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return key, fmt.Errorf("fetch API key: %w", err)
+	}
+	return key, nil
+}
+
+// FetchAPIKeys returns every key, including revoked ones, oldest first.
+func (p *PostgresDB) FetchAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	p.ensureConnection()
+
+	rows, err := p.conn().QueryContext(ctx, fetchAPIKeysQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the key with the given ID and returns it; ErrAPIKeyNotFound
+// is returned when no such key exists or it was already revoked.
+func (p *PostgresDB) RevokeAPIKey(ctx context.Context, id int64) (models.APIKey, error) {
+	p.ensureConnection()
+
+	key, err := scanAPIKey(p.conn().QueryRowContext(ctx, revokeAPIKeyQuery, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return key, fmt.Errorf("revoke API key: %w", err)
+	}
+	return key, nil
+}
+
+// TouchAPIKey records that the key was just used.
+func (p *PostgresDB) TouchAPIKey(ctx context.Context, id int64) error {
+	p.ensureConnection()
+
+	if _, err := p.conn().ExecContext(ctx, touchAPIKeyQuery, id); err != nil {
+		return fmt.Errorf("touch API key: %w", err)
+	}
+	return nil
+}
+
+// scanAPIKey reads a row of apiKeyColumns.
+func scanAPIKey(row interface{ Scan(...any) error }) (models.APIKey, error) {
+	var key models.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.TenantID, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedBy, &key.CreatedAt,
+		&lastUsedAt, &revokedAt)
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, err
+}
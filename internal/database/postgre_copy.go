@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+)
+
+// MessageCopy streams messages into the messages table with COPY. Nothing is
+// visible to other sessions before Commit.
+type MessageCopy struct {
+	tx       *sql.Tx
+	stmt     *sql.Stmt
+	tenantID string
+	count    int
+}
+
+// BeginMessageCopy starts a COPY of messages of the tenant in its own transaction.
+func (p *PostgresDB) BeginMessageCopy(ctx context.Context, tenantID string) (*MessageCopy, error) {
+	p.ensureConnection()
+
+	tx, err := p.conn().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("messages", "id", "tenant_id", "content", "phone_number"))
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("prepare copy: %w", err)
+	}
+	return &MessageCopy{tx: tx, stmt: stmt, tenantID: tenantID}, nil
+}
+
+// Add queues a pending message; rows are sent to Postgres in the background.
+func (c *MessageCopy) Add(ctx context.Context, id, content, phoneNumber string) error {
+	if _, err := c.stmt.ExecContext(ctx, id, c.tenantID, content, phoneNumber); err != nil {
+		return fmt.Errorf("copy message: %w", err)
+	}
+	c.count++
+	return nil
+}
+
+// Commit flushes the COPY and commits it, returning the number of messages stored.
+func (c *MessageCopy) Commit(ctx context.Context) (int, error) {
+	defer c.tx.Rollback()
+
+	// an Exec without arguments ends the COPY
+	if _, err := c.stmt.ExecContext(ctx); err != nil {
+		return 0, fmt.Errorf("flush copy: %w", err)
+	}
+	if err := c.stmt.Close(); err != nil {
+		return 0, fmt.Errorf("close copy: %w", err)
+	}
+	if err := c.tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return c.count, nil
+}
+
+// Rollback aborts the COPY; nothing is stored.
+func (c *MessageCopy) Rollback() {
+	_ = c.stmt.Close()
+	_ = c.tx.Rollback()
+}
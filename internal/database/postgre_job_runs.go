@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"messaging-server/internal/models"
+	"strings"
+)
+
+const insertJobRunQuery = `
+        INSERT INTO job_runs (job_name, status, started_at, ended_at, duration_ms, skipped, instance_id, fencing_token)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id
+    `
+
+const finishJobRunQuery = `
+        UPDATE job_runs
+           SET status = $2, ended_at = $3, duration_ms = $4, fetched = $5, sent = $6, failed = $7, error_summary = $8
+         WHERE id = $1
+    `
+
+// purgeJobRunsQuery deletes one batch of finished runs started more than $1 seconds ago.
+const purgeJobRunsQuery = `
+        DELETE FROM job_runs
+         WHERE id IN (
+               SELECT id FROM job_runs
+                WHERE started_at < now() - $1 * interval '1 second' AND status <> 'running'
+                LIMIT $2
+         )
+    `
+
+const jobRunColumns = `
+        id, job_name, status, started_at, ended_at, duration_ms, fetched, sent, failed, skipped, COALESCE(error_summary, ''),
+        COALESCE(instance_id, ''), fencing_token
+    `
+
+// InsertJobRun stores the start of a job run and sets its ID.
+func (p *PostgresDB) InsertJobRun(ctx context.Context, run *models.JobRun) error {
+	p.ensureConnection()
+
+	err := p.conn().QueryRowContext(ctx, insertJobRunQuery, run.JobName, run.Status, run.StartedAt, run.EndedAt, run.DurationMs, run.Skipped,
+		nullable(run.InstanceID), run.FencingToken).
+		Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("inserting job run: %w", err)
+	}
+	return nil
+}
+
+// FinishJobRun stores the outcome of a job run.
+func (p *PostgresDB) FinishJobRun(ctx context.Context, run *models.JobRun) error {
+	p.ensureConnection()
+
+	_, err := p.conn().ExecContext(ctx, finishJobRunQuery, run.ID, run.Status, run.EndedAt, run.DurationMs,
+		run.Fetched, run.Sent, run.Failed, nullable(run.ErrorSummary))
+	if err != nil {
+		return fmt.Errorf("finishing job run %d: %w", run.ID, err)
+	}
+	return nil
+}
+
+// PurgeJobRuns deletes the finished runs started more than age seconds ago in
+// batches of batchSize, so no single statement holds locks for long. It
+// returns the number of runs deleted.
+func (p *PostgresDB) PurgeJobRuns(ctx context.Context, age, batchSize int) (int64, error) {
+	p.ensureConnection()
+
+	var total int64
+	for {
+		res, err := p.conn().ExecContext(ctx, purgeJobRunsQuery, age, batchSize)
+		if err != nil {
+			return total, fmt.Errorf("purging job runs: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("rows affected: %w", err)
+		}
+		total += n
+		if n < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// FetchJobRun returns the job run with the given ID, or nil if there is none.
+func (p *PostgresDB) FetchJobRun(ctx context.Context, id int64) (*models.JobRun, error) {
+	p.ensureConnection()
+
+	var run models.JobRun
+	err := p.conn().QueryRowContext(ctx, "SELECT "+jobRunColumns+" FROM job_runs WHERE id = $1", id).
+		Scan(&run.ID, &run.JobName, &run.Status, &run.StartedAt, &run.EndedAt, &run.DurationMs,
+			&run.Fetched, &run.Sent, &run.Failed, &run.Skipped, &run.ErrorSummary,
+			&run.InstanceID, &run.FencingToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching job run %d: %w", id, err)
+	}
+	return &run, nil
+}
+
+// FetchJobRuns returns the job runs matching filter, newest first, and the total number of matches.
+func (p *PostgresDB) FetchJobRuns(ctx context.Context, filter models.JobRunFilter) ([]models.JobRun, int, error) {
+	p.ensureConnection()
+
+	// build the WHERE clause from the filter
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.JobName != "" {
+		add("job_name = $%d", filter.JobName)
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.Skipped != nil {
+		add("skipped = $%d", *filter.Skipped)
+	}
+	if filter.From != nil {
+		add("started_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("started_at < $%d", *filter.To)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := p.conn().QueryRowContext(ctx, "SELECT COUNT(*) FROM job_runs"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count job runs: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM job_runs%s ORDER BY started_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		jobRunColumns, where, len(args)+1, len(args)+2)
+	rows, err := p.conn().QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query job runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.JobRun{}
+	for rows.Next() {
+		var run models.JobRun
+		if err := rows.Scan(&run.ID, &run.JobName, &run.Status, &run.StartedAt, &run.EndedAt, &run.DurationMs,
+			&run.Fetched, &run.Sent, &run.Failed, &run.Skipped, &run.ErrorSummary,
+			&run.InstanceID, &run.FencingToken); err != nil {
+			return nil, 0, fmt.Errorf("scan job run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	return runs, total, nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"messaging-server/internal/models"
+)
+
+const fetchRuntimeSettingsQuery = `
+        SELECT key, value FROM runtime_settings
+    `
+
+const upsertRuntimeSettingQuery = `
+        INSERT INTO runtime_settings (key, value, updated_at, updated_by)
+        VALUES ($1, $2, now(), $3)
+            ON CONFLICT (key) DO UPDATE
+           SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by
+    `
+
+const insertSettingAuditQuery = `
+        INSERT INTO settings_audit (key, old_value, new_value, actor, remote_addr)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+
+const fetchSettingsAuditQuery = `
+        SELECT id, key, old_value, new_value, actor, COALESCE(remote_addr, ''), changed_at
+          FROM settings_audit
+         ORDER BY changed_at DESC, id DESC
+         LIMIT $1 OFFSET $2
+    `
+
+// FetchRuntimeSettings returns the persisted runtime setting overrides by key.
+func (p *PostgresDB) FetchRuntimeSettings(ctx context.Context) (map[string]string, error) {
+	p.ensureConnection()
+
+	rows, err := p.conn().QueryContext(ctx, fetchRuntimeSettingsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query runtime settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("scan runtime setting: %w", err)
+		}
+		settings[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return settings, nil
+}
+
+// SaveRuntimeSettings persists changed settings and their audit entries in a single transaction.
+func (p *PostgresDB) SaveRuntimeSettings(ctx context.Context, changes []models.SettingChange, actor, remoteAddr string) error {
+	p.ensureConnection()
+
+	tx, err := p.conn().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, change := range changes {
+		if _, err := tx.ExecContext(ctx, upsertRuntimeSettingQuery, change.Key, change.NewValue, actor); err != nil {
+			return fmt.Errorf("saving setting %s: %w", change.Key, err)
+		}
+		if _, err := tx.ExecContext(ctx, insertSettingAuditQuery, change.Key, change.OldValue, change.NewValue,
+			actor, nullable(remoteAddr)); err != nil {
+			return fmt.Errorf("auditing setting %s: %w", change.Key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FetchSettingsAudit returns the audit entries of runtime setting changes, newest first,
+// and the total number of entries.
+func (p *PostgresDB) FetchSettingsAudit(ctx context.Context, limit, offset int) ([]models.SettingAudit, int, error) {
+	p.ensureConnection()
+
+	var total int
+	if err := p.conn().QueryRowContext(ctx, "SELECT COUNT(*) FROM settings_audit").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count settings audit: %w", err)
+	}
+
+	rows, err := p.conn().QueryContext(ctx, fetchSettingsAuditQuery, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query settings audit: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.SettingAudit{}
+	for rows.Next() {
+		var e models.SettingAudit
+		if err := rows.Scan(&e.ID, &e.Key, &e.OldValue, &e.NewValue, &e.Actor, &e.RemoteAddr, &e.ChangedAt); err != nil {
+			return nil, 0, fmt.Errorf("scan settings audit: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"messaging-server/internal/models"
+)
+
+var (
+	// ErrTenantNotFound is returned when no tenant has the given ID.
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrTenantExists is returned when creating a tenant whose ID is taken.
+	ErrTenantExists = errors.New("tenant already exists")
+)
+
+const tenantColumns = `id, name, COALESCE(webhook_url, ''), rate_limit, rate_burst, created_at`
+
+const insertTenantQuery = `
+        INSERT INTO tenants (id, name, webhook_url, rate_limit, rate_burst)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING ` + tenantColumns
+
+const updateTenantQuery = `
+        UPDATE tenants
+           SET name = $2, webhook_url = $3, rate_limit = $4, rate_burst = $5
+         WHERE id = $1
+        RETURNING ` + tenantColumns
+
+const fetchTenantQuery = `SELECT ` + tenantColumns + ` FROM tenants WHERE id = $1`
+
+const fetchTenantsQuery = `SELECT ` + tenantColumns + ` FROM tenants ORDER BY id`
+
+// InsertTenant creates a tenant; ErrTenantExists is returned when the ID is taken.
+func (p *PostgresDB) InsertTenant(ctx context.Context, id string, cfg models.TenantConfig) (models.Tenant, error) {
+	p.ensureConnection()
+
+	t, err := scanTenant(p.conn().QueryRowContext(ctx, insertTenantQuery, id, cfg.Name, nullable(cfg.WebhookURL),
+		nullableInt(cfg.RateLimit), nullableInt(cfg.RateBurst)))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return t, ErrTenantExists
+	}
+	if err != nil {
+		return t, fmt.Errorf("insert tenant: %w", err)
+	}
+	return t, nil
+}
+
+// UpdateTenant replaces the configuration of a tenant; ErrTenantNotFound is
+// returned when it does not exist.
+func (p *PostgresDB) UpdateTenant(ctx context.Context, id string, cfg models.TenantConfig) (models.Tenant, error) {
+	p.ensureConnection()
+
+	t, err := scanTenant(p.conn().QueryRowContext(ctx, updateTenantQuery, id, cfg.Name, nullable(cfg.WebhookURL),
+		nullableInt(cfg.RateLimit), nullableInt(cfg.RateBurst)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, ErrTenantNotFound
+	}
+	if err != nil {
+		return t, fmt.Errorf("update tenant: %w", err)
+	}
+	return t, nil
+}
+
+// FetchTenant returns the tenant with the given ID, or ErrTenantNotFound.
+func (p *PostgresDB) FetchTenant(ctx context.Context, id string) (models.Tenant, error) {
+	p.ensureConnection()
+
+	t, err := scanTenant(p.conn().QueryRowContext(ctx, fetchTenantQuery, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, ErrTenantNotFound
+	}
+	if err != nil {
+		return t, fmt.Errorf("fetch tenant: %w", err)
+	}
+	return t, nil
+}
+
+// FetchTenants returns every tenant by ID.
+func (p *PostgresDB) FetchTenants(ctx context.Context) ([]models.Tenant, error) {
+	p.ensureConnection()
+
+	rows, err := p.conn().QueryContext(ctx, fetchTenantsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("query tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []models.Tenant{}
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan tenant: %w", err)
+		}
+		tenants = append(tenants, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return tenants, nil
+}
+
+// scanTenant reads a row of tenantColumns.
+func scanTenant(row interface{ Scan(...any) error }) (models.Tenant, error) {
+	var t models.Tenant
+	var rateLimit, rateBurst sql.NullInt32
+	err := row.Scan(&t.ID, &t.Name, &t.WebhookURL, &rateLimit, &rateBurst, &t.CreatedAt)
+	if rateLimit.Valid {
+		v := int(rateLimit.Int32)
+		t.RateLimit = &v
+	}
+	if rateBurst.Valid {
+		v := int(rateBurst.Int32)
+		t.RateBurst = &v
+	}
+	return t, err
+}
+
+// nullableInt maps a nil int to SQL NULL.
+func nullableInt(v *int) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*v), Valid: true}
+}
+
+// isForeignKeyViolation reports whether err is a foreign key violation, e.g. an unknown tenant.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -2,18 +2,28 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"messaging-server/internal/configs"
 	log "messaging-server/internal/logging"
 	"messaging-server/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type RedisClientTemplate struct {
-	client *redis.Client
-	ctx    context.Context
-	ttl    time.Duration
+	current atomic.Pointer[redis.Client]
+	ctx     context.Context
+	ttl     time.Duration
+
+	// reconnectMu serializes replacing the client
+	reconnectMu sync.Mutex
 }
 
 var RedisClient *RedisClientTemplate
@@ -24,6 +34,20 @@ func ConnectRedis() error {
 		return nil
 	}
 
+	client, err := dialRedis()
+	if err != nil {
+		return err
+	}
+
+	r := &RedisClientTemplate{ctx: context.Background(), ttl: time.Duration(configs.RedisConfig.TTL) * time.Second}
+	r.current.Store(client)
+	RedisClient = r
+	log.Logger.Info("Redis connection established")
+	return nil
+}
+
+// dialRedis dials Redis with the configured settings and pings it.
+func dialRedis() (*redis.Client, error) {
 	cfg := configs.RedisConfig
 
 	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
@@ -38,36 +62,461 @@ func ConnectRedis() error {
 	// fail-fast ping
 	ctx := context.Background()
 	if err := rdb.Ping(ctx).Err(); err != nil {
-		return fmt.Errorf("redis ping failed: %w", err)
+		_ = rdb.Close()
+		return nil, fmt.Errorf("redis ping failed: %w", err)
 	}
 
-	RedisClient = &RedisClientTemplate{client: rdb, ctx: ctx, ttl: time.Duration(cfg.TTL) * time.Second}
-	log.Logger.Info("Redis connection established")
+	return rdb, nil
+}
+
+// client returns the current Redis client.
+func (r *RedisClientTemplate) client() *redis.Client {
+	return r.current.Load()
+}
+
+// ensureConnection checks if the Redis connection is alive and reconnects if not.
+// The current client is kept when reconnecting fails, so callers can retry later.
+// Concurrent callers seeing the same lost client reconnect once.
+func (r *RedisClientTemplate) ensureConnection() error {
+	client := r.client()
+	err := client.Ping(r.ctx).Err()
+	if err == nil {
+		return nil
+	}
+
+	r.reconnectMu.Lock()
+	defer r.reconnectMu.Unlock()
+	// another caller already replaced the client
+	if r.client() != client {
+		return nil
+	}
+
+	log.Logger.Warningf("lost Redis connection (%v), reconnecting…", err)
+	fresh, err := dialRedis()
+	if err != nil {
+		return fmt.Errorf("redis reconnect failed: %w", err)
+	}
+	r.current.Store(fresh)
+	_ = client.Close()
+	return nil
+}
+
+// sentKey returns the Redis key holding the sent outcome of a message.
+func sentKey(id string) string {
+	return "sent:" + id
+}
+
+// InsertRecord stores the sent outcome of a message in Redis with the specified TTL
+func (r *RedisClientTemplate) InsertRecord(ctx context.Context, rec models.RedisRecord) error {
+	if err := r.ensureConnection(); err != nil {
+		return err
+	}
+	key := sentKey(rec.ID)
+	_, err := r.client().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "messageId", rec.MessageID, "sentAt", rec.SentAt, "idempotencyKey", rec.IdempotencyKey)
+		pipe.Expire(ctx, key, r.ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis HSET failed: %w", err)
+	}
+	log.Logger.Debugf("Redis HSET succeeded for key: %s", key)
 	return nil
 }
 
-// ensureConnection checks if the Redis connection is alive and reconnects if not
-func (r *RedisClientTemplate) ensureConnection() {
-	if err := r.client.Ping(r.ctx).Err(); err != nil {
-		log.Logger.Warningf("lost Redis connection (%v), reconnecting…", err)
-		_ = r.client.Close()
+// FetchRecords returns the sent records stored for the given message IDs, keyed
+// by ID. Messages without a record, e.g. because it expired, are left out.
+func (r *RedisClientTemplate) FetchRecords(ctx context.Context, ids []string) (map[string]models.RedisRecord, error) {
+	if err := r.ensureConnection(); err != nil {
+		return nil, err
+	}
 
-		// clear so ConnectRedis will re-init
-		RedisClient = nil
-		if err := ConnectRedis(); err != nil {
-			log.Logger.Fatalf("Redis reconnect failed: %v", err)
+	cmds := make([]*redis.StringStringMapCmd, len(ids))
+	_, err := r.client().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, sentKey(id))
 		}
-		// reset receiver to the fresh global
-		*r = *RedisClient
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redis HGETALL failed: %w", err)
 	}
+
+	records := make(map[string]models.RedisRecord, len(ids))
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			continue
+		}
+		records[ids[i]] = models.RedisRecord{
+			ID:             ids[i],
+			MessageID:      fields["messageId"],
+			SentAt:         fields["sentAt"],
+			IdempotencyKey: fields["idempotencyKey"],
+		}
+	}
+	return records, nil
+}
+
+// takeTokenScript implements a token bucket shared by every replica.
+// It returns 0 when a token was taken, otherwise the number of milliseconds
+// the caller has to wait before trying again.
+var takeTokenScript = redis.NewScript(`
+local pause = redis.call('PTTL', KEYS[2])
+if pause > 0 then
+	return pause
+end
+
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return wait
+`)
+
+// TakeToken tries to take a token from the shared bucket of the given provider
+// and returns how long the caller has to wait if none was available.
+func (r *RedisClientTemplate) TakeToken(ctx context.Context, provider string, rate, burst int) (time.Duration, error) {
+	if err := r.ensureConnection(); err != nil {
+		return 0, err
+	}
+	keys := []string{"ratelimit:" + provider + ":bucket", "ratelimit:" + provider + ":pause"}
+	wait, err := takeTokenScript.Run(ctx, r.client(), keys, rate, burst).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("redis token bucket failed: %w", err)
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+// slidingWindowScript counts the requests of a client within a sliding window
+// shared by every replica. Each allowed request is a member of a sorted set
+// scored by its time; rejected requests are not recorded, so retrying does not
+// extend the wait. It returns whether the request was allowed, the requests
+// left and the milliseconds until the oldest request leaves the window.
+var slidingWindowScript = redis.NewScript(`
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+// SlideWindow records a request of client against its sliding window of limit
+// requests per window, unless the window is already full.
+func (r *RedisClientTemplate) SlideWindow(ctx context.Context, client string, limit int, window time.Duration) (models.RateLimitStatus, error) {
+	status := models.RateLimitStatus{Limit: limit}
+	if err := r.ensureConnection(); err != nil {
+		return status, err
+	}
+	keys := []string{"ratelimit:client:" + client}
+	res, err := slidingWindowScript.Run(ctx, r.client(), keys, window.Milliseconds(), limit, uuid.NewString()).Int64Slice()
+	if err != nil {
+		return status, fmt.Errorf("redis sliding window failed: %w", err)
+	}
+	status.Allowed = res[0] == 1
+	status.Remaining = int(res[1])
+	status.Reset = time.Duration(res[2]) * time.Millisecond
+	return status, nil
 }
 
-// InsertRecord inserts a new record into Redis with the specified TTL
-func (r *RedisClientTemplate) InsertRecord(rec models.RedisRecord) error {
-	r.ensureConnection()
-	if err := r.client.Set(r.ctx, rec.MessageID, rec.SentAt, r.ttl).Err(); err != nil {
+// PauseProvider blocks the shared bucket of the given provider for d.
+func (r *RedisClientTemplate) PauseProvider(ctx context.Context, provider string, d time.Duration) error {
+	if err := r.ensureConnection(); err != nil {
+		return err
+	}
+	if err := r.client().Set(ctx, "ratelimit:"+provider+":pause", 1, d).Err(); err != nil {
 		return fmt.Errorf("redis SET failed: %w", err)
 	}
-	log.Logger.Debugf("Redis SET succeeded for key: %s", rec.MessageID)
 	return nil
 }
+
+// ScanSentRecords returns every sent outcome currently held in Redis, keyed by message ID.
+func (r *RedisClientTemplate) ScanSentRecords(ctx context.Context) (map[string]models.RedisRecord, error) {
+	if err := r.ensureConnection(); err != nil {
+		return nil, err
+	}
+
+	records := map[string]models.RedisRecord{}
+	iter := r.client().Scan(ctx, 0, sentKey("*"), 500).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		fields, err := r.client().HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis HGETALL %s failed: %w", key, err)
+		}
+		// the key may have expired between SCAN and HGETALL
+		if len(fields) == 0 {
+			continue
+		}
+		id := strings.TrimPrefix(key, sentKey(""))
+		records[id] = models.RedisRecord{
+			ID:             id,
+			MessageID:      fields["messageId"],
+			SentAt:         fields["sentAt"],
+			IdempotencyKey: fields["idempotencyKey"],
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("redis SCAN failed: %w", err)
+	}
+
+	return records, nil
+}
+
+// acquireLeaseScript takes the lease when it is free and hands out the next
+// fencing token. It returns 0 when another instance holds the lease.
+var acquireLeaseScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+local token = redis.call('INCR', KEYS[2])
+redis.call('SET', KEYS[1], ARGV[1] .. '|' .. token, 'PX', ARGV[2])
+return token
+`)
+
+// renewLeaseScript extends the lease if it is still held with the given value.
+var renewLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLeaseScript deletes the lease if it is still held with the given value.
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// leaseValue is the value stored in a lease key.
+func leaseValue(holder string, token int64) string {
+	return fmt.Sprintf("%s|%d", holder, token)
+}
+
+// AcquireLease takes the named lease for holder and returns its fencing
+// token, or 0 when another holder owns the lease.
+func (r *RedisClientTemplate) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (int64, error) {
+	if err := r.ensureConnection(); err != nil {
+		return 0, err
+	}
+	keys := []string{"lease:" + name, "lease:" + name + ":token"}
+	token, err := acquireLeaseScript.Run(ctx, r.client(), keys, holder, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("redis lease acquire failed: %w", err)
+	}
+	return token, nil
+}
+
+// RenewLease extends the named lease and reports whether holder still owns it.
+func (r *RedisClientTemplate) RenewLease(ctx context.Context, name, holder string, token int64, ttl time.Duration) (bool, error) {
+	if err := r.ensureConnection(); err != nil {
+		return false, err
+	}
+	renewed, err := renewLeaseScript.Run(ctx, r.client(), []string{"lease:" + name}, leaseValue(holder, token), ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, fmt.Errorf("redis lease renew failed: %w", err)
+	}
+	return renewed == 1, nil
+}
+
+// ReleaseLease gives up the named lease if holder still owns it.
+func (r *RedisClientTemplate) ReleaseLease(ctx context.Context, name, holder string, token int64) error {
+	if err := r.ensureConnection(); err != nil {
+		return err
+	}
+	if err := releaseLeaseScript.Run(ctx, r.client(), []string{"lease:" + name}, leaseValue(holder, token)).Err(); err != nil {
+		return fmt.Errorf("redis lease release failed: %w", err)
+	}
+	return nil
+}
+
+// LeaseHolder returns the current holder and fencing token of the named lease.
+// An empty holder means the lease is free.
+func (r *RedisClientTemplate) LeaseHolder(ctx context.Context, name string) (string, int64, error) {
+	if err := r.ensureConnection(); err != nil {
+		return "", 0, err
+	}
+	value, err := r.client().Get(ctx, "lease:"+name).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("redis GET failed: %w", err)
+	}
+
+	sep := strings.LastIndex(value, "|")
+	if sep < 0 {
+		return value, 0, nil
+	}
+	token, _ := strconv.ParseInt(value[sep+1:], 10, 64)
+	return value[:sep], token, nil
+}
+
+// ErrStreamTokenNotFound is returned for stream tokens that expired, were used or never existed.
+var ErrStreamTokenNotFound = errors.New("stream token not found")
+
+// streamTokenKey is the key holding a stream token, stored by its digest.
+func streamTokenKey(token string) string {
+	return "streamtoken:" + models.HashAPIKey(token)
+}
+
+// StoreStreamToken stores a stream token acting as key until it is used or ttl passed.
+func (r *RedisClientTemplate) StoreStreamToken(ctx context.Context, token string, key models.APIKey, ttl time.Duration) error {
+	if err := r.ensureConnection(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("marshal stream token: %w", err)
+	}
+	if err := r.client().Set(ctx, streamTokenKey(token), data, ttl).Err(); err != nil {
+		return fmt.Errorf("redis SET failed: %w", err)
+	}
+	return nil
+}
+
+// TakeStreamToken returns the key a stream token acts as and deletes the token,
+// so it opens a single stream.
+func (r *RedisClientTemplate) TakeStreamToken(ctx context.Context, token string) (models.APIKey, error) {
+	var key models.APIKey
+	if err := r.ensureConnection(); err != nil {
+		return key, err
+	}
+	data, err := r.client().GetDel(ctx, streamTokenKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return key, ErrStreamTokenNotFound
+	}
+	if err != nil {
+		return key, fmt.Errorf("redis GETDEL failed: %w", err)
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return key, fmt.Errorf("unmarshal stream token: %w", err)
+	}
+	return key, nil
+}
+
+// eventStreamKey is the Redis stream holding the message lifecycle events.
+const eventStreamKey = "events:messages"
+
+// AppendEvent adds ev to the event stream, trimming it to about maxLen events,
+// and returns the stream ID of the event.
+func (r *RedisClientTemplate) AppendEvent(ctx context.Context, ev models.MessageEvent, maxLen int64) (string, error) {
+	if err := r.ensureConnection(); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return "", fmt.Errorf("marshal event: %w", err)
+	}
+	id, err := r.client().XAdd(ctx, &redis.XAddArgs{
+		Stream: eventStreamKey,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: []interface{}{"event", data},
+	}).Result()
+	if err != nil {
+		return "", fmt.Errorf("redis XADD failed: %w", err)
+	}
+	return id, nil
+}
+
+// LastEventID returns the stream ID of the newest event, or "0-0" when the stream is empty.
+func (r *RedisClientTemplate) LastEventID(ctx context.Context) (string, error) {
+	if err := r.ensureConnection(); err != nil {
+		return "", err
+	}
+	msgs, err := r.client().XRevRangeN(ctx, eventStreamKey, "+", "-", 1).Result()
+	if err != nil {
+		return "", fmt.Errorf("redis XREVRANGE failed: %w", err)
+	}
+	if len(msgs) == 0 {
+		return "0-0", nil
+	}
+	return msgs[0].ID, nil
+}
+
+// ReadEvents waits up to block for events newer than the stream ID after and returns at most count of them.
+// No events and no error are returned when none arrived in time.
+func (r *RedisClientTemplate) ReadEvents(ctx context.Context, after string, count int64, block time.Duration) ([]models.MessageEvent, error) {
+	if err := r.ensureConnection(); err != nil {
+		return nil, err
+	}
+	streams, err := r.client().XRead(ctx, &redis.XReadArgs{
+		Streams: []string{eventStreamKey, after},
+		Count:   count,
+		Block:   block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis XREAD failed: %w", err)
+	}
+	var events []models.MessageEvent
+	for _, stream := range streams {
+		events = append(events, parseEvents(stream.Messages)...)
+	}
+	return events, nil
+}
+
+// EventsAfter returns at most count events newer than the stream ID after, oldest first.
+func (r *RedisClientTemplate) EventsAfter(ctx context.Context, after string, count int64) ([]models.MessageEvent, error) {
+	if err := r.ensureConnection(); err != nil {
+		return nil, err
+	}
+	msgs, err := r.client().XRangeN(ctx, eventStreamKey, "("+after, "+", count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis XRANGE failed: %w", err)
+	}
+	return parseEvents(msgs), nil
+}
+
+// parseEvents decodes stream entries into events; malformed entries are skipped.
+func parseEvents(msgs []redis.XMessage) []models.MessageEvent {
+	events := make([]models.MessageEvent, 0, len(msgs))
+	for _, msg := range msgs {
+		data, _ := msg.Values["event"].(string)
+		var ev models.MessageEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			log.Logger.Warningf("skipping malformed event %s: %v", msg.ID, err)
+			continue
+		}
+		ev.ID = msg.ID
+		events = append(events, ev)
+	}
+	return events
+}
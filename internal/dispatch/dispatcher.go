@@ -0,0 +1,71 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"messaging-server/internal/cron"
+	log "messaging-server/internal/logging"
+	"time"
+)
+
+// Dispatcher runs a job as soon as it is notified of new work. Notifications
+// arriving within the debounce window are coalesced into a single run.
+type Dispatcher struct {
+	job      *cron.Cron
+	debounce time.Duration
+	wake     chan struct{}
+}
+
+// NewDispatcher returns a Dispatcher running job at most once per debounce window.
+func NewDispatcher(job *cron.Cron, debounce time.Duration) *Dispatcher {
+	return &Dispatcher{
+		job:      job,
+		debounce: debounce,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Notify signals new work without blocking.
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default: // a run is already pending
+	}
+}
+
+// Run triggers the job for notifications until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-d.wake:
+		case <-ctx.Done():
+			return
+		}
+
+		// let a burst of inserts settle into one run
+		timer := time.NewTimer(d.debounce)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		// a job stopped through the control API stays stopped
+		if !d.job.Status().Running {
+			log.Logger.Debugf("%s is stopped; ignoring new messages", d.job.Name())
+			continue
+		}
+
+		// followers and busy jobs leave the work to the leader or the in-flight runs;
+		// the scheduled tick picks up anything left
+		switch err := d.job.Trigger(); {
+		case errors.Is(err, cron.ErrNotLeader):
+			log.Logger.Debugf("not the leader; leaving new messages to the leader")
+		case err != nil:
+			log.Logger.Debugf("dispatch of %s skipped: %v", d.job.Name(), err)
+		default:
+			log.Logger.Debugf("dispatched %s for new messages", d.job.Name())
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package events
+
+import (
+	"context"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// subscriberBuffer is the number of events a subscriber may fall behind before it is dropped.
+	subscriberBuffer = 256
+	// readBlock is how long the broker waits for new events per read.
+	readBlock = 5 * time.Second
+	// readCount is the largest number of events read from the stream at once.
+	readCount = 500
+	// publishBuffer is the number of events waiting to be published before new ones are dropped.
+	publishBuffer = 4096
+	// publishTimeout bounds appending a single event to the stream.
+	publishTimeout = 2 * time.Second
+	// flushTimeout bounds publishing the events still queued at shutdown.
+	flushTimeout = 2 * time.Second
+)
+
+// published holds the events waiting for RunPublisher to append them to the stream.
+var published = make(chan models.MessageEvent, publishBuffer)
+
+// dropping is set while Publish drops events, so a full buffer is logged once.
+var dropping atomic.Bool
+
+// Publish queues a lifecycle event for the event stream shared by every replica.
+// Events are best effort: Publish never blocks the send path, and events that
+// do not fit into the buffer while Redis is slow or down are dropped.
+func Publish(ev models.MessageEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	select {
+	case published <- ev:
+	default:
+		if !dropping.Swap(true) {
+			log.Logger.Warningf("event buffer full; dropping the %s event of message %s and later events until it drains", ev.Status, ev.MessageID)
+		}
+	}
+}
+
+// RunPublisher appends the queued events to the event stream until ctx is
+// done, then appends the events still queued for up to flushTimeout.
+func RunPublisher(ctx context.Context) {
+	for {
+		select {
+		case ev := <-published:
+			appendEvent(ev)
+		case <-ctx.Done():
+			deadline := time.Now().Add(flushTimeout)
+			for time.Now().Before(deadline) {
+				select {
+				case ev := <-published:
+					appendEvent(ev)
+				default:
+					return
+				}
+			}
+			return
+		}
+	}
+}
+
+// appendEvent appends one queued event to the event stream.
+func appendEvent(ev models.MessageEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if _, err := database.RedisClient.AppendEvent(ctx, ev, configs.AppConfig.EventStreamLength); err != nil {
+		log.Logger.Warningf("failed to publish %s event of message %s: %v", ev.Status, ev.MessageID, err)
+		return
+	}
+	if dropping.Swap(false) {
+		log.Logger.Info("event buffer drained; publishing events again")
+	}
+}
+
+// Broker tails the event stream and fans the events out to the subscribers of this replica.
+type Broker struct {
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	stopped bool
+}
+
+// Subscription receives the live events matching its filter.
+// C is closed when the subscriber falls behind or the broker stops.
+type Subscription struct {
+	C <-chan models.MessageEvent
+
+	c      chan models.MessageEvent
+	filter models.EventFilter
+	broker *Broker
+}
+
+// NewBroker returns a Broker without subscribers.
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe registers a subscriber for the live events matching filter.
+func (b *Broker) Subscribe(filter models.EventFilter) *Subscription {
+	c := make(chan models.MessageEvent, subscriberBuffer)
+	sub := &Subscription{C: c, c: c, filter: filter, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		close(c)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close unregisters the subscriber.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s)
+}
+
+// drop removes the subscriber and closes its channel; b.mu must be held.
+func (b *Broker) drop(s *Subscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
+
+// Replay returns the events newer than the stream ID after matching filter, oldest first.
+// It reaches back as far as the stream is kept (EVENT_STREAM_LENGTH events).
+func (b *Broker) Replay(ctx context.Context, after string, filter models.EventFilter) ([]models.MessageEvent, error) {
+	var matched []models.MessageEvent
+	for {
+		page, err := database.RedisClient.EventsAfter(ctx, after, readCount)
+		if err != nil {
+			return nil, err
+		}
+		for _, ev := range page {
+			if filter.Match(ev) {
+				matched = append(matched, ev)
+			}
+		}
+		if len(page) < readCount {
+			return matched, nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+// Run tails the event stream until ctx is done. Every subscription is closed
+// as soon as ctx is done, so streaming clients do not hold up a shutdown.
+func (b *Broker) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		b.stop()
+	}()
+
+	var last string
+	for ctx.Err() == nil {
+		var err error
+		if last == "" {
+			// start at the end of the stream; older events are replayed on request
+			last, err = database.RedisClient.LastEventID(ctx)
+		} else {
+			var batch []models.MessageEvent
+			batch, err = database.RedisClient.ReadEvents(ctx, last, readCount, readBlock)
+			for _, ev := range batch {
+				b.fanOut(ev)
+				last = ev.ID
+			}
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Logger.Errorf("failed to read the event stream: %v", err)
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+// fanOut delivers ev to every matching subscriber, dropping the ones that fell behind.
+func (b *Broker) fanOut(ev models.MessageEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if !sub.filter.Match(ev) {
+			continue
+		}
+		select {
+		case sub.c <- ev:
+		default:
+			// the client resumes from its last event after reconnecting
+			log.Logger.Warningf("dropping event subscriber that fell %d events behind", subscriberBuffer)
+			b.drop(sub)
+		}
+	}
+}
+
+// stop closes every subscription and rejects new ones.
+func (b *Broker) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	for sub := range b.subs {
+		b.drop(sub)
+	}
+}
+
+// ValidID reports whether id is an event stream ID of the form <milliseconds>-<sequence>.
+func ValidID(id string) bool {
+	_, _, ok := parseID(id)
+	return ok
+}
+
+// After reports whether the event stream ID a is newer than b; both must be valid.
+func After(a, b string) bool {
+	aMs, aSeq, _ := parseID(a)
+	bMs, bSeq, _ := parseID(b)
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	return aSeq > bSeq
+}
+
+// parseID splits an event stream ID into its time and sequence parts.
+func parseID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
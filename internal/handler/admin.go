@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"messaging-server/internal/cron"
+	"messaging-server/internal/database"
+	"messaging-server/internal/jobs"
+	"messaging-server/internal/models"
+	"messaging-server/internal/settings"
+	"net/http"
+	"strconv"
+)
+
+// defaultActor is recorded in the settings audit when no X-Admin-Actor header is given.
+const defaultActor = "admin"
+
+// ReconciliationReportHandler returns the report of the last reconciliation run.
+// @Summary      Reconciliation report
+// @Description  Returns the drift found between Redis and Postgres by the last reconciliation run.
+// @Description  The report spans every tenant, so it requires ADMIN_TOKEN.
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object} models.ReconciliationReport  "Reconciliation report fetched successfully"
+// @Failure      404  "no reconciliation run yet"
+// @Router       /api/v1/admin/reconciliation [get]
+func ReconciliationReportHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := jobs.LatestReconciliationReport()
+		if report == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no reconciliation run yet"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Reconciliation report fetched successfully", "data": report})
+	}
+}
+
+// GetSettingsHandler returns the runtime settings in effect.
+// @Summary      Runtime settings
+// @Description  Returns the send job interval, fetch limit and concurrency limit in effect.
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object} models.RuntimeSettings  "Runtime settings fetched successfully"
+// @Failure      401  "invalid or missing admin token"
+// @Router       /api/v1/admin/settings [get]
+func GetSettingsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "Runtime settings fetched successfully", "data": settings.Current()})
+	}
+}
+
+// UpdateSettingsHandler changes runtime settings of the send job.
+// @Summary      Update runtime settings
+// @Description  Changes the send job interval (seconds), fetch limit (at most ADAPTIVE_MAX_BATCH) and concurrency limit without a restart.
+// @Description  Omitted fields are left unchanged. Changes are persisted, survive restarts and are audited
+// @Description  under the X-Admin-Actor header (defaults to "admin").
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        X-Admin-Actor  header    string                 false  "Who makes the change"
+// @Param        payload        body      models.SettingsUpdate  true   "settings to change"
+// @Success      200  {object} models.RuntimeSettings  "Runtime settings updated successfully"
+// @Failure      400  "invalid setting"
+// @Failure      401  "invalid or missing admin token"
+// @Failure      500  "failed to update runtime settings"
+// @Router       /api/v1/admin/settings [patch]
+func UpdateSettingsHandler(scheduler *cron.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var update models.SettingsUpdate
+		if err := c.ShouldBindJSON(&update); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+
+		send, ok := scheduler.Job(jobs.SendJobName)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "send job is not registered"})
+			return
+		}
+
+		actor := c.GetHeader("X-Admin-Actor")
+		if actor == "" {
+			actor = defaultActor
+		}
+
+		current, err := settings.Update(c.Request.Context(), send, update, actor, c.ClientIP())
+		if errors.Is(err, settings.ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update runtime settings", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Runtime settings updated successfully", "data": current})
+	}
+}
+
+// ListSettingsAuditHandler returns the audit trail of runtime setting changes.
+// @Summary      Runtime settings audit
+// @Description  Lists every runtime setting change newest first, with its old and new value, actor and time.
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        limit    query     int     false  "Page size (default 50, max 500)"
+// @Param        offset   query     int     false  "Number of entries to skip"
+// @Success      200  {object} []models.SettingAudit  "Settings audit fetched successfully"
+// @Failure      400  "invalid query parameter"
+// @Failure      401  "invalid or missing admin token"
+// @Failure      500  "failed to fetch settings audit"
+// @Router       /api/v1/admin/settings/audit [get]
+func ListSettingsAuditHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, offset := defaultPageLimit, 0
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > maxPageLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+				return
+			}
+			limit = n
+		}
+		if v := c.Query("offset"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+				return
+			}
+			offset = n
+		}
+
+		entries, total, err := database.PostgresConnection.FetchSettingsAudit(c.Request.Context(), limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch settings audit", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Settings audit fetched successfully",
+			"data":    entries,
+			"pagination": gin.H{
+				"limit":  limit,
+				"offset": offset,
+				"total":  total,
+			},
+		})
+	}
+}
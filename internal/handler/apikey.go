@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"net/http"
+	"strconv"
+)
+
+// CreateAPIKeyHandler creates an API key.
+// @Summary      Create API key
+// @Description  Creates an API key of a tenant (default: "default") granted the given scopes (messages:read,
+// @Description  messages:write, cron:read, cron:admin). Requests made with the key only see the messages of its
+// @Description  tenant; cron scopes are reserved to the default tenant. The key is only returned in this
+// @Description  response; only its SHA-256 digest is stored.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        X-Admin-Actor  header    string                      false  "Who creates the key"
+// @Param        payload        body      models.CreateAPIKeyRequest  true   "key name and scopes"
+// @Success      201  {object} models.CreatedAPIKey  "API key created successfully"
+// @Failure      400  "invalid API key request or unknown tenant"
+// @Failure      401  "invalid or missing admin token"
+// @Failure      500  "failed to create API key"
+// @Router       /api/v1/admin/keys [post]
+func CreateAPIKeyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CreateAPIKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := req.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		actor := c.GetHeader("X-Admin-Actor")
+		if actor == "" {
+			actor = defaultActor
+		}
+
+		if req.TenantID == "" {
+			req.TenantID = models.DefaultTenant
+		}
+
+		secret, prefix, err := models.GenerateAPIKey()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create API key", "details": err.Error()})
+			return
+		}
+		key, err := database.PostgresConnection.InsertAPIKey(c.Request.Context(), req.Name, req.TenantID, prefix,
+			models.HashAPIKey(secret), req.Scopes, actor)
+		if errors.Is(err, database.ErrTenantNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown tenant " + req.TenantID})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create API key", "details": err.Error()})
+			return
+		}
+
+		log.Logger.Infof("API key %d (%s) of tenant %s created by %s with scopes %v", key.ID, key.Name, key.TenantID, actor, key.Scopes)
+		c.JSON(http.StatusCreated, gin.H{"message": "API key created successfully", "data": models.CreatedAPIKey{APIKey: key, Key: secret}})
+	}
+}
+
+// ListAPIKeysHandler lists every API key.
+// @Summary      List API keys
+// @Description  Lists every API key, including revoked ones, with its scopes and when it was last used.
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object} []models.APIKey  "API keys fetched successfully"
+// @Failure      401  "invalid or missing admin token"
+// @Failure      500  "failed to fetch API keys"
+// @Router       /api/v1/admin/keys [get]
+func ListAPIKeysHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := database.PostgresConnection.FetchAPIKeys(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch API keys", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "API keys fetched successfully", "data": keys})
+	}
+}
+
+// RevokeAPIKeyHandler revokes an API key.
+// @Summary      Revoke API key
+// @Description  Revokes an API key; requests using it are rejected right away. The key stays listed.
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        id   path      int  true  "API key ID"
+// @Success      200  {object} models.APIKey  "API key revoked successfully"
+// @Failure      400  "invalid API key ID"
+// @Failure      401  "invalid or missing admin token"
+// @Failure      404  "API key not found or already revoked"
+// @Failure      500  "failed to revoke API key"
+// @Router       /api/v1/admin/keys/{id} [delete]
+func RevokeAPIKeyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID"})
+			return
+		}
+
+		key, err := database.PostgresConnection.RevokeAPIKey(c.Request.Context(), id)
+		if errors.Is(err, database.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found or already revoked"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke API key", "details": err.Error()})
+			return
+		}
+
+		log.Logger.Infof("API key %d (%s) revoked", key.ID, key.Name)
+		c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully", "data": key})
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"messaging-server/internal/configs"
+	"net/http"
+	"strings"
+)
+
+// APIKeyContextKey holds the API key a request was authenticated with; the
+// authentication middleware sets it.
+const APIKeyContextKey = "apiKey"
+
+// AdminAuth only lets through requests carrying "Authorization: Bearer <ADMIN_TOKEN>".
+// Without a configured ADMIN_TOKEN every request is rejected.
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := configs.AppConfig.AdminToken
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin API is disabled; set ADMIN_TOKEN to enable it"})
+			return
+		}
+
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing admin token"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,25 +1,45 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"messaging-server/internal/configs"
 	"messaging-server/internal/cron"
+	"messaging-server/internal/database"
+	"messaging-server/internal/jobs"
+	"messaging-server/internal/leader"
 	log "messaging-server/internal/logging"
 	"messaging-server/internal/models"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-// CronHandler handles both starting and stopping the cron based on the "action" field.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// CronHandler starts, stops or runs a job based on the "action" field.
 // @Summary      Control cron job
-// @Description  Start or stop the cron based on the "action" field.
+// @Description  Start, stop or immediately run the job named in the "job" field (defaults to "send").
+// @Description  Stopping drains in-flight runs unless "mode" is "cancel".
+// @Description  "run" (or its alias "trigger") executes the job right away through the same concurrency limit
+// @Description  and returns the run ID to poll at /api/v1/cron/runs/{id}; "overrides.fetchLimit" changes the
+// @Description  number of messages claimed per batch by that send run only, up to ADAPTIVE_MAX_BATCH.
 // @Tags         Cron
 // @Accept       json
 // @Produce      json
-// @Param        payload  body      models.CronRequest  true  "start or stop"
+// @Security     ApiKey
+// @Param        payload  body      models.CronRequest  true  "job name and start, stop or run"
 // @Success      200        "Cron job started"
-// @Success      202        "Cron job will be stopped"
+// @Success      202        "Cron job will be stopped / run started"
 // @Failure      400        "Invalid request payload"
+// @Failure      404        "Job not found"
+// @Failure      409        "Max concurrency reached, job stopped or instance is not the leader"
 // @Router       /api/v1/cron/control [post]
-func CronHandler(cronJob *cron.Cron) gin.HandlerFunc {
+func CronHandler(scheduler *cron.Scheduler) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req models.CronRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -27,18 +47,231 @@ func CronHandler(cronJob *cron.Cron) gin.HandlerFunc {
 			return
 		}
 
+		if req.Job == "" {
+			req.Job = jobs.SendJobName
+		}
+		cronJob, ok := scheduler.Job(req.Job)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found", "job": req.Job})
+			return
+		}
+
 		switch req.Action {
 		case "start":
 			cronJob.Start()
-			c.JSON(http.StatusOK, gin.H{"message": "Cron job started"})
+			c.JSON(http.StatusOK, gin.H{"message": "Cron job started", "job": req.Job})
 		case "stop":
+			mode := cron.StopMode(req.Mode)
+			if mode == "" {
+				mode = cron.StopDrain
+			}
+			if mode != cron.StopDrain && mode != cron.StopCancel {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be 'drain' or 'cancel'"})
+				return
+			}
 			go func() {
-				cronJob.Stop()
-				log.Logger.Info("background cron.Stop() goroutine finished")
+				cronJob.Stop(mode)
+				log.Logger.Infof("background cron.Stop() goroutine for %s finished", req.Job)
 			}()
-			c.JSON(http.StatusAccepted, gin.H{"message": "Cron job will be stopped"})
+			c.JSON(http.StatusAccepted, gin.H{"message": "Cron job will be stopped", "job": req.Job, "mode": mode})
+		case "run", "trigger":
+			// a single run must not claim the whole queue into sending
+			if limit := configs.AppConfig.AdaptiveMaxBatch; req.Overrides.FetchLimit < 0 || req.Overrides.FetchLimit > limit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("overrides.fetchLimit must be between 0 and %d (ADAPTIVE_MAX_BATCH)", limit)})
+				return
+			}
+			if req.Overrides.FetchLimit > 0 && req.Job != jobs.SendJobName {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "overrides.fetchLimit only applies to the send job"})
+				return
+			}
+			run, err := cronJob.RunNow(req.Overrides)
+			if err != nil {
+				resp := gin.H{"error": err.Error(), "job": req.Job}
+				if errors.Is(err, cron.ErrNotLeader) {
+					resp["details"] = "trigger the job on the leader, see /api/v1/cron/leader"
+				}
+				c.JSON(http.StatusConflict, resp)
+				return
+			}
+			c.JSON(http.StatusAccepted, gin.H{"message": "Cron job run started", "job": req.Job, "runId": run.ID})
 		default:
-			c.JSON(http.StatusBadRequest, gin.H{"error": "action must be 'start' or 'stop'"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "action must be 'start', 'stop' or 'run'"})
 		}
 	}
 }
+
+// ListJobsHandler returns the status of every scheduled job.
+// @Summary      List cron jobs
+// @Description  Lists every registered job with its schedule, running state, next run and in-flight count,
+// @Description  along with the leader election status under "leader".
+// @Tags         Cron
+// @Produce      json
+// @Security     ApiKey
+// @Success      200  {object} []models.JobStatus  "Jobs fetched successfully"
+// @Router       /api/v1/cron/jobs [get]
+func ListJobsHandler(scheduler *cron.Scheduler, elector *leader.Elector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Jobs fetched successfully",
+			"data":    scheduler.Statuses(),
+			"leader":  elector.Status(c.Request.Context()),
+		})
+	}
+}
+
+// LeaderHandler returns the leader election status.
+// @Summary      Leader status
+// @Description  Returns whether this instance leads, the current leader and its fencing token.
+// @Tags         Cron
+// @Produce      json
+// @Security     ApiKey
+// @Success      200  {object} models.LeaderStatus  "Leader status fetched successfully"
+// @Router       /api/v1/cron/leader [get]
+func LeaderHandler(elector *leader.Elector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "Leader status fetched successfully", "data": elector.Status(c.Request.Context())})
+	}
+}
+
+// GetJobHandler returns the status of a single scheduled job.
+// @Summary      Get cron job
+// @Description  Returns the schedule, running state, next run and in-flight count of a job.
+// @Tags         Cron
+// @Produce      json
+// @Security     ApiKey
+// @Param        name  path      string  true  "Job name"
+// @Success      200  {object} models.JobStatus  "Job fetched successfully"
+// @Failure      404  "job not found"
+// @Router       /api/v1/cron/jobs/{name} [get]
+func GetJobHandler(scheduler *cron.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cronJob, ok := scheduler.Job(c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Job fetched successfully", "data": cronJob.Status()})
+	}
+}
+
+// ListRunsHandler returns the persisted history of job runs.
+// @Summary      List job runs
+// @Description  Lists job runs newest first, optionally filtered by job, status, skipped flag and start time range.
+// @Tags         Cron
+// @Produce      json
+// @Security     ApiKey
+// @Param        job      query     string  false  "Job name"
+// @Param        status   query     string  false  "running, succeeded, failed, timed_out, cancelled or skipped"
+// @Param        skipped  query     bool    false  "Only skipped (true) or executed (false) runs"
+// @Param        from     query     string  false  "Runs started at or after this RFC3339 time"
+// @Param        to       query     string  false  "Runs started before this RFC3339 time"
+// @Param        limit    query     int     false  "Page size (default 50, max 500)"
+// @Param        offset   query     int     false  "Number of runs to skip"
+// @Success      200  {object} []models.JobRun  "Job runs fetched successfully"
+// @Failure      400  "invalid query parameter"
+// @Failure      500  "failed to fetch job runs"
+// @Router       /api/v1/cron/runs [get]
+func ListRunsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := parseRunFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		runs, total, err := database.PostgresConnection.FetchJobRuns(c.Request.Context(), filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch job runs", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Job runs fetched successfully",
+			"data":    runs,
+			"pagination": gin.H{
+				"limit":  filter.Limit,
+				"offset": filter.Offset,
+				"total":  total,
+			},
+		})
+	}
+}
+
+// GetRunHandler returns a single job run, e.g. to poll the outcome of a manual run.
+// @Summary      Get job run
+// @Description  Returns the status, counters and error summary of a job run.
+// @Tags         Cron
+// @Produce      json
+// @Security     ApiKey
+// @Param        id   path      int  true  "Run ID"
+// @Success      200  {object} models.JobRun  "Job run fetched successfully"
+// @Failure      400  "invalid run ID"
+// @Failure      404  "job run not found"
+// @Failure      500  "failed to fetch job run"
+// @Router       /api/v1/cron/runs/{id} [get]
+func GetRunHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid run ID"})
+			return
+		}
+
+		run, err := database.PostgresConnection.FetchJobRun(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch job run", "details": err.Error()})
+			return
+		}
+		if run == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job run not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Job run fetched successfully", "data": run})
+	}
+}
+
+// parseRunFilter reads the job run filter from the query string.
+func parseRunFilter(c *gin.Context) (models.JobRunFilter, error) {
+	filter := models.JobRunFilter{
+		JobName: c.Query("job"),
+		Status:  c.Query("status"),
+		Limit:   defaultPageLimit,
+	}
+
+	if v := c.Query("skipped"); v != "" {
+		skipped, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("skipped must be a boolean")
+		}
+		filter.Skipped = &skipped
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("from must be an RFC3339 time")
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("to must be an RFC3339 time")
+		}
+		filter.To = &to
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		filter.Limit = limit
+	}
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, fmt.Errorf("offset must be a non-negative integer")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
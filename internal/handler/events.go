@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"messaging-server/internal/database"
+	"messaging-server/internal/events"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// eventHeartbeat is how often an idle event stream is kept alive.
+	eventHeartbeat = 15 * time.Second
+	// wsWriteTimeout bounds every write to a WebSocket client.
+	wsWriteTimeout = 10 * time.Second
+	// streamTokenTTL is how long a stream token can be used.
+	streamTokenTTL = time.Minute
+)
+
+// errStreamClosed is returned by streamEvents when the subscription ended
+// because the client fell behind or the server is shutting down.
+var errStreamClosed = errors.New("event stream closed")
+
+// upgrader upgrades event stream requests to WebSocket connections.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// CreateStreamTokenHandler issues a single-use token opening one event stream.
+// @Summary      Create stream token
+// @Description  Issues a token for clients that can not set headers on the event stream request, like
+// @Description  EventSource and browser WebSockets. It is passed as the token query parameter, opens a single
+// @Description  stream of the tenant of the request and expires after a minute, so API keys never end up in URLs.
+// @Tags         Events
+// @Produce      json
+// @Security     ApiKey
+// @Success      201  {object} models.StreamToken  "Stream token created successfully"
+// @Failure      500  "failed to create stream token"
+// @Router       /api/v1/events/token [post]
+func CreateStreamTokenHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// the token only grants reading the events of the tenant of the request
+		key := models.APIKey{Name: "stream token"}
+		if v, ok := c.Get(APIKeyContextKey); ok {
+			key = v.(models.APIKey)
+		}
+		key.TenantID = tenantID(c)
+		key.Scopes = []string{models.ScopeMessagesRead}
+
+		token, err := models.GenerateStreamToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create stream token", "details": err.Error()})
+			return
+		}
+		if err := database.RedisClient.StoreStreamToken(c.Request.Context(), token, key, streamTokenTTL); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create stream token", "details": err.Error()})
+			return
+		}
+
+		data := models.StreamToken{Token: token, ExpiresAt: time.Now().Add(streamTokenTTL).UTC()}
+		c.JSON(http.StatusCreated, gin.H{"message": "Stream token created successfully", "data": data})
+	}
+}
+
+// EventsHandler streams message lifecycle events as Server-Sent Events.
+// @Summary      Message event stream
+// @Description  Pushes message lifecycle events (claimed, sent, failed) as Server-Sent Events while they happen.
+// @Description  Every event carries its ID; reconnecting with the Last-Event-ID header, or the last_event_id
+// @Description  query parameter, first replays the events missed in between. A client falling too far
+// @Description  behind is disconnected and resumes the same way.
+// @Tags         Events
+// @Produce      text/event-stream
+// @Security     ApiKey
+// @Param        phone_number   query     string  false  "Only events of messages to this recipient"
+// @Param        status         query     string  false  "Comma-separated event types: claimed, sent, failed, delivered"
+// @Param        last_event_id  query     string  false  "Resume after this event"
+// @Param        token          query     string  false  "Stream token from POST /api/v1/events/token"
+// @Param        Last-Event-ID  header    string  false  "Resume after this event"
+// @Success      200  {object} models.MessageEvent  "Event stream"
+// @Failure      400  "invalid query parameter"
+// @Failure      500  "failed to replay events"
+// @Router       /api/v1/events [get]
+func EventsHandler(broker *events.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, lastID, err := parseEventStream(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		sub := broker.Subscribe(filter)
+		defer sub.Close()
+
+		// replay before committing to a stream so a failure is still reported as such
+		backlog, err := replayEvents(c.Request.Context(), broker, filter, lastID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to replay events", "details": err.Error()})
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		// keep reverse proxies from buffering the stream
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		send := func(ev models.MessageEvent) error {
+			data, err := json.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Status, data); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		}
+		heartbeat := func() error {
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		}
+
+		err = streamEvents(c.Request.Context(), sub, backlog, lastID, send, heartbeat)
+		log.Logger.Debugf("event stream of %s ended: %v", c.ClientIP(), err)
+	}
+}
+
+// EventsWebSocketHandler streams message lifecycle events over a WebSocket.
+// @Summary      Message event WebSocket
+// @Description  WebSocket equivalent of GET /api/v1/events: every lifecycle event is sent as a JSON text
+// @Description  message. Reconnecting with last_event_id replays the events missed in between. The server
+// @Description  closes with code 1013 when the client fell too far behind or the server shuts down.
+// @Tags         Events
+// @Security     ApiKey
+// @Param        phone_number   query     string  false  "Only events of messages to this recipient"
+// @Param        status         query     string  false  "Comma-separated event types: claimed, sent, failed, delivered"
+// @Param        last_event_id  query     string  false  "Resume after this event"
+// @Param        token          query     string  false  "Stream token from POST /api/v1/events/token"
+// @Success      101  {object} models.MessageEvent  "Switching to the WebSocket protocol"
+// @Failure      400  "invalid query parameter"
+// @Failure      500  "failed to replay events"
+// @Router       /api/v1/events/ws [get]
+func EventsWebSocketHandler(broker *events.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, lastID, err := parseEventStream(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		sub := broker.Subscribe(filter)
+		defer sub.Close()
+
+		backlog, err := replayEvents(c.Request.Context(), broker, filter, lastID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to replay events", "details": err.Error()})
+			return
+		}
+
+		// the upgrader answers failed handshakes itself
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			log.Logger.Debugf("websocket upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// the client only sends control frames; reading processes them and notices a disconnect
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		go func() {
+			defer cancel()
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		send := func(ev models.MessageEvent) error {
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			return conn.WriteJSON(ev)
+		}
+		heartbeat := func() error {
+			return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+		}
+
+		err = streamEvents(ctx, sub, backlog, lastID, send, heartbeat)
+		if errors.Is(err, errStreamClosed) {
+			msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect with last_event_id")
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+		}
+		log.Logger.Debugf("event websocket of %s ended: %v", c.ClientIP(), err)
+	}
+}
+
+// parseEventStream reads the event filter and the event to resume after.
+func parseEventStream(c *gin.Context) (models.EventFilter, string, error) {
+	filter := models.EventFilter{TenantID: tenantID(c), PhoneNumber: c.Query("phone_number")}
+	if v := c.Query("status"); v != "" {
+		for _, status := range strings.Split(v, ",") {
+			status = strings.TrimSpace(status)
+			switch status {
+			case models.EventClaimed, models.EventSent, models.EventFailed, models.EventDelivered:
+			default:
+				return filter, "", fmt.Errorf("status must be a comma-separated list of claimed, sent, failed or delivered")
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if lastID != "" && !events.ValidID(lastID) {
+		return filter, "", fmt.Errorf("last event ID must be an event ID, e.g. 1700000000000-0")
+	}
+	return filter, lastID, nil
+}
+
+// replayEvents returns the events after lastID matching filter; none when lastID is empty.
+func replayEvents(ctx context.Context, broker *events.Broker, filter models.EventFilter, lastID string) ([]models.MessageEvent, error) {
+	if lastID == "" {
+		return nil, nil
+	}
+	return broker.Replay(ctx, lastID, filter)
+}
+
+// streamEvents sends the backlog and then the live events of sub until ctx is
+// done, the subscription ends or sending fails. Live events already sent as
+// part of the backlog are skipped; heartbeat runs whenever the stream was idle
+// for eventHeartbeat.
+func streamEvents(ctx context.Context, sub *events.Subscription, backlog []models.MessageEvent, lastID string,
+	send func(models.MessageEvent) error, heartbeat func() error) error {
+
+	for _, ev := range backlog {
+		if err := send(ev); err != nil {
+			return err
+		}
+		lastID = ev.ID
+	}
+
+	ticker := time.NewTicker(eventHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				return errStreamClosed
+			}
+			if lastID != "" && !events.After(ev.ID, lastID) {
+				continue
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+			lastID = ev.ID
+			ticker.Reset(eventHeartbeat)
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
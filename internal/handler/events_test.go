@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"messaging-server/internal/events"
+	"messaging-server/internal/models"
+	"reflect"
+	"testing"
+)
+
+// runStream streams backlog and live through streamEvents until live is drained and returns the sent IDs.
+func runStream(t *testing.T, backlog []models.MessageEvent, lastID string, live ...string) ([]string, error) {
+	t.Helper()
+	c := make(chan models.MessageEvent, len(live))
+	for _, id := range live {
+		c <- models.MessageEvent{ID: id}
+	}
+	close(c)
+
+	var sent []string
+	err := streamEvents(context.Background(), &events.Subscription{C: c}, backlog, lastID,
+		func(ev models.MessageEvent) error {
+			sent = append(sent, ev.ID)
+			return nil
+		},
+		func() error { return nil })
+	return sent, err
+}
+
+func TestStreamEventsSkipsReplayedEvents(t *testing.T) {
+	backlog := []models.MessageEvent{{ID: "100-0"}, {ID: "100-1"}}
+
+	// live events overlapping the backlog are dropped, later ones are sent once
+	sent, err := runStream(t, backlog, "99-0", "100-0", "100-1", "100-2", "100-2", "101-0")
+	if !errors.Is(err, errStreamClosed) {
+		t.Fatalf("streamEvents() = %v, want errStreamClosed", err)
+	}
+	if want := []string{"100-0", "100-1", "100-2", "101-0"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("sent %v, want %v", sent, want)
+	}
+}
+
+func TestStreamEventsResumesAfterLastID(t *testing.T) {
+	sent, _ := runStream(t, nil, "200-0", "199-9", "200-0", "200-1")
+	if want := []string{"200-1"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("sent %v, want %v", sent, want)
+	}
+}
+
+func TestStreamEventsWithoutLastID(t *testing.T) {
+	sent, _ := runStream(t, nil, "", "3-0", "5-0")
+	if want := []string{"3-0", "5-0"}; !reflect.DeepEqual(sent, want) {
+		t.Fatalf("sent %v, want %v", sent, want)
+	}
+}
+
+func TestStreamEventsStopsOnSendError(t *testing.T) {
+	c := make(chan models.MessageEvent, 1)
+	c <- models.MessageEvent{ID: "1-0"}
+
+	errGone := errors.New("client gone")
+	err := streamEvents(context.Background(), &events.Subscription{C: c}, nil, "",
+		func(models.MessageEvent) error { return errGone },
+		func() error { return nil })
+	if !errors.Is(err, errGone) {
+		t.Fatalf("streamEvents() = %v, want the send error", err)
+	}
+}
+
+func TestStreamEventsStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := streamEvents(ctx, &events.Subscription{C: make(chan models.MessageEvent)}, nil, "",
+		func(models.MessageEvent) error { return nil },
+		func() error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("streamEvents() = %v, want context.Canceled", err)
+	}
+}
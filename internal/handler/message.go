@@ -1,27 +1,617 @@
 package handler
 
 import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io"
+	"messaging-server/internal/configs"
 	"messaging-server/internal/database"
+	"messaging-server/internal/importer"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// ListMessageHandler gets all messages that have been sent and returns a JSON response.
+// CreateMessageHandler enqueues a message for sending.
+// @Summary      Create message
+// @Description  Validates and enqueues a message; the ID is generated by the server. The message is sent
+// @Description  by the next send run.
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Security     ApiKey
+// @Param        payload  body      models.CreateMessageRequest  true  "content and E.164 phone_number"
+// @Success      201  {object} models.Message  "Message created successfully"
+// @Failure      400  "invalid request payload"
+// @Failure      429  "rate limit exceeded"
+// @Failure      500  "failed to create message"
+// @Router       /api/v1/messages [post]
+func CreateMessageHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CreateMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := req.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		msg, err := database.PostgresConnection.InsertMessage(c.Request.Context(), tenantID(c), uuid.NewString(), req.Content, req.PhoneNumber)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create message", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Message created successfully", "data": msg})
+	}
+}
+
+// ImportMessagesHandler enqueues the messages of a CSV or NDJSON upload.
+// @Summary      Import messages
+// @Description  Streams a CSV (header with phone_number and content columns) or NDJSON upload into the queue.
+// @Description  The upload is either the request body or the "file" field of a multipart form; the format is
+// @Description  taken from the "format" query parameter, the file name or the content type. Invalid rows are
+// @Description  rejected and listed by line number; the valid rows are stored together.
+// @Tags         Messages
+// @Accept       text/csv,application/x-ndjson,multipart/form-data
+// @Produce      json
+// @Security     ApiKey
+// @Param        format  query     string  false  "csv or ndjson"
+// @Param        file    formData  file    false  "upload, when sent as a multipart form"
+// @Success      200  {object} models.ImportReport  "Messages imported"
+// @Failure      400  "invalid upload"
+// @Failure      413  "upload too large"
+// @Failure      429  "rate limit exceeded"
+// @Failure      500  "failed to import messages"
+// @Router       /api/v1/messages/import [post]
+func ImportMessagesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, configs.AppConfig.ImportMaxBytes)
+
+		var body io.Reader = c.Request.Body
+		filename := ""
+		if c.ContentType() == "multipart/form-data" {
+			file, header, err := c.Request.FormFile("file")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "multipart upload needs a 'file' field", "details": err.Error()})
+				return
+			}
+			defer file.Close()
+			body, filename = file, header.Filename
+		}
+
+		format := importer.DetectFormat(c.Query("format"), filename, c.ContentType())
+		report, err := importer.Import(c.Request.Context(), tenantID(c), body, format)
+
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "upload too large", "limit": tooLarge.Limit})
+		case errors.Is(err, importer.ErrUnknownFormat):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, importer.ErrInvalidUpload):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import messages", "details": err.Error()})
+		default:
+			c.JSON(http.StatusOK, gin.H{"message": "Messages imported", "data": report})
+		}
+	}
+}
+
+// ListMessagesHandler returns a page of messages, newest first.
+// @Summary      List messages
+// @Description  Lists messages newest first, filtered by status, recipient, creation time range and content.
+// @Description  Pass the returned next_cursor as "cursor" to fetch the following page; it is null on the last page.
+// @Tags         Messages
+// @Produce      json
+// @Security     ApiKey
+// @Param        status        query     string  false  "pending, sending, sent or cancelled"
+// @Param        phone_number  query     string  false  "Recipient"
+// @Param        from          query     string  false  "Messages created at or after this RFC3339 time"
+// @Param        to            query     string  false  "Messages created before this RFC3339 time"
+// @Param        q             query     string  false  "Case-insensitive text contained in the content"
+// @Param        limit         query     int     false  "Page size (default 50, max 500)"
+// @Param        cursor        query     string  false  "next_cursor of the previous page"
+// @Success      200  {object} []models.Message  "Messages fetched successfully"
+// @Failure      400  "invalid query parameter"
+// @Failure      500  "failed to fetch messages"
+// @Router       /api/v1/messages [get]
+func ListMessagesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := parseMessageFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		listMessages(c, filter)
+	}
+}
+
+// ExportMessagesHandler streams every message matching a filter as CSV or NDJSON.
+// @Summary      Export messages
+// @Description  Streams the messages matching the filters of GET /api/v1/messages, oldest first, as a CSV or
+// @Description  NDJSON download with chunked transfer. The export reads a consistent snapshot through a
+// @Description  server-side cursor; a response cut short by an error is aborted instead of completed.
+// @Tags         Messages
+// @Produce      text/csv,application/x-ndjson
+// @Security     ApiKey
+// @Param        format        query     string  false  "csv (default) or ndjson"
+// @Param        status        query     string  false  "pending, sending, sent or cancelled"
+// @Param        phone_number  query     string  false  "Recipient"
+// @Param        from          query     string  false  "Messages created at or after this RFC3339 time"
+// @Param        to            query     string  false  "Messages created before this RFC3339 time"
+// @Param        q             query     string  false  "Case-insensitive text contained in the content"
+// @Success      200  "Messages exported"
+// @Failure      400  "invalid query parameter"
+// @Router       /api/v1/messages/export [get]
+func ExportMessagesHandler() gin.HandlerFunc {
+	return exportMessages(func(ctx context.Context, filter models.MessageFilter, emit func([]models.MessageDetails) error) error {
+		return database.PostgresConnection.ExportMessages(ctx, filter, emit)
+	})
+}
+
+// exportFunc streams the messages matching filter to emit, one batch at a time.
+type exportFunc func(ctx context.Context, filter models.MessageFilter, emit func([]models.MessageDetails) error) error
+
+// exportMessages streams the messages read by export in the requested format.
+func exportMessages(export exportFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := parseMessageFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.After = nil
+
+		format := c.DefaultQuery("format", importer.FormatCSV)
+		var write func([]models.MessageDetails) error
+		switch format {
+		case importer.FormatCSV:
+			c.Header("Content-Type", "text/csv")
+			w := csv.NewWriter(c.Writer)
+			header := false
+			write = func(batch []models.MessageDetails) error {
+				if !header {
+					header = true
+					if err := w.Write(exportColumns); err != nil {
+						return err
+					}
+				}
+				for _, d := range batch {
+					if err := w.Write(exportRecord(d)); err != nil {
+						return err
+					}
+				}
+				w.Flush()
+				return w.Error()
+			}
+		case importer.FormatNDJSON:
+			c.Header("Content-Type", "application/x-ndjson")
+			enc := json.NewEncoder(c.Writer)
+			write = func(batch []models.MessageDetails) error {
+				for _, d := range batch {
+					if err := enc.Encode(d); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": importer.ErrUnknownFormat.Error()})
+			return
+		}
+
+		filename := fmt.Sprintf("messages-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+
+		rows := 0
+		err = export(c.Request.Context(), filter, func(batch []models.MessageDetails) error {
+			if err := write(batch); err != nil {
+				return err
+			}
+			// send every batch right away instead of buffering the export
+			c.Writer.Flush()
+			rows += len(batch)
+			return nil
+		})
+		if err != nil {
+			log.Logger.Errorf("message export aborted after %d rows: %v", rows, err)
+			// an export that stopped half way must not look complete: Recovery passes
+			// this panic on to net/http, which drops the connection without ending the body
+			panic(http.ErrAbortHandler)
+		}
+
+		// an empty CSV export still gets its header
+		if format == importer.FormatCSV && rows == 0 {
+			_ = write(nil)
+		}
+		log.Logger.Infof("exported %d messages as %s", rows, format)
+	}
+}
+
+// exportColumns is the header of a CSV export.
+var exportColumns = []string{
+	"id", "content", "phone_number", "status", "is_sent", "send_generation",
+	"created_at", "claimed_at", "provider_message_id", "sent_at", "version",
+}
+
+// exportRecord formats a message as a CSV export row.
+func exportRecord(d models.MessageDetails) []string {
+	claimedAt := ""
+	if d.ClaimedAt != nil {
+		claimedAt = d.ClaimedAt.Format(time.RFC3339)
+	}
+	return []string{
+		d.ID, d.Content, d.PhoneNumber, d.Status, strconv.FormatBool(d.IsSent), strconv.Itoa(d.SendGeneration),
+		d.CreatedAt.Format(time.RFC3339), claimedAt, d.ProviderMessageID, d.SentAt, strconv.Itoa(d.Version),
+	}
+}
+
+// ListMessageHandler gets the messages that have been sent and returns a JSON response.
 // @Summary      List sent messages
-// @Description  Retrieves all messages that have been sent.
+// @Description  Deprecated: use GET /api/v1/messages?status=sent. Takes the same query parameters, with the
+// @Description  status fixed to sent.
 // @Tags         Messages
 // @Produce      json
+// @Security     ApiKey
+// @Param        limit   query     int     false  "Page size (default 50, max 500)"
+// @Param        cursor  query     string  false  "next_cursor of the previous page"
 // @Success      200  {object} []models.Message      "Messages fetched successfully"
+// @Failure      400   "invalid query parameter"
 // @Failure      500   "failed to fetch messages"
+// @Deprecated
 // @Router       /api/v1/list/sent-messages [get]
 func ListMessageHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", `</api/v1/messages?status=sent>; rel="successor-version"`)
 
-		msgs, err := database.PostgresConnection.FetchAllSentMessages()
+		filter, err := parseMessageFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Status = models.StatusSent
+		listMessages(c, filter)
+	}
+}
+
+// listMessages writes the page of messages selected by filter.
+func listMessages(c *gin.Context, filter models.MessageFilter) {
+	msgs, next, err := database.PostgresConnection.FetchMessages(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch messages", "details": err.Error()})
+		return
+	}
+
+	var nextCursor *string
+	if next != nil {
+		encoded := next.Encode()
+		nextCursor = &encoded
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Messages fetched successfully", "data": msgs, "next_cursor": nextCursor})
+}
+
+// parseMessageFilter reads the message filter from the query string.
+func parseMessageFilter(c *gin.Context) (models.MessageFilter, error) {
+	filter := models.MessageFilter{
+		TenantID:    tenantID(c),
+		Status:      c.Query("status"),
+		PhoneNumber: c.Query("phone_number"),
+		Query:       c.Query("q"),
+		Limit:       defaultPageLimit,
+	}
+
+	switch filter.Status {
+	case "", models.StatusPending, models.StatusSending, models.StatusSent, models.StatusCancelled:
+	default:
+		return filter, fmt.Errorf("status must be one of pending, sending, sent or cancelled")
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("from must be an RFC3339 time")
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("to must be an RFC3339 time")
+		}
+		filter.To = &to
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		filter.Limit = limit
+	}
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := models.ParseMessageCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &cursor
+	}
+
+	return filter, nil
+}
+
+// GetMessageHandler returns a message merged with its delivery details.
+// @Summary      Get message
+// @Description  Returns the stored message along with the provider message ID and send time, read from Redis
+// @Description  while its record exists and from Postgres once it expired.
+// @Tags         Messages
+// @Produce      json
+// @Security     ApiKey
+// @Param        id   path      string  true  "Message ID"
+// @Success      200  {object} models.MessageDetails  "Message fetched successfully"
+// @Failure      404  "message not found"
+// @Failure      500  "failed to fetch message"
+// @Router       /api/v1/messages/{id} [get]
+func GetMessageHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		details, err := lookupMessages(c.Request.Context(), tenantID(c), []string{id})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch message", "details": err.Error()})
+			return
+		}
+		if len(details) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+		c.Header("ETag", details[0].ETag())
+		c.JSON(http.StatusOK, gin.H{"message": "Message fetched successfully", "data": details[0]})
+	}
+}
+
+// EditMessageHandler changes the content or phone number of a pending message.
+// @Summary      Edit message
+// @Description  Changes a message that has not been claimed for sending yet. Send the ETag of
+// @Description  GET /api/v1/messages/{id} as If-Match to make sure nobody changed the message in the meantime.
+// @Description  The edited message gets a new idempotency key.
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Security     ApiKey
+// @Param        id        path      string                     true   "Message ID"
+// @Param        If-Match  header    string                     false  "ETag of the version being edited"
+// @Param        payload   body      models.EditMessageRequest  true   "content and/or phone_number"
+// @Success      200  {object} models.MessageDetails  "Message updated successfully"
+// @Failure      400  "invalid request payload"
+// @Failure      404  "message not found"
+// @Failure      409  "message is no longer pending"
+// @Failure      412  "message version mismatch"
+// @Failure      500  "failed to update message"
+// @Router       /api/v1/messages/{id} [patch]
+func EditMessageHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := parseIfMatch(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var req models.EditMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := req.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		msg, err := database.PostgresConnection.EditPendingMessage(c.Request.Context(), tenantID(c), c.Param("id"), req.Content, req.PhoneNumber, version)
+		if err != nil {
+			writePendingError(c, err, "failed to update message")
+			return
+		}
+		c.Header("ETag", msg.ETag())
+		c.JSON(http.StatusOK, gin.H{"message": "Message updated successfully", "data": msg})
+	}
+}
+
+// CancelMessageHandler cancels a pending message.
+// @Summary      Cancel message
+// @Description  Cancels a message that has not been claimed for sending yet, so it is never sent. Send the ETag
+// @Description  of GET /api/v1/messages/{id} as If-Match to make sure nobody changed the message in the meantime.
+// @Tags         Messages
+// @Produce      json
+// @Security     ApiKey
+// @Param        id        path      string  true   "Message ID"
+// @Param        If-Match  header    string  false  "ETag of the version being cancelled"
+// @Success      200  {object} models.MessageDetails  "Message cancelled successfully"
+// @Failure      400  "invalid If-Match header"
+// @Failure      404  "message not found"
+// @Failure      409  "message is no longer pending"
+// @Failure      412  "message version mismatch"
+// @Failure      500  "failed to cancel message"
+// @Router       /api/v1/messages/{id} [delete]
+func CancelMessageHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := parseIfMatch(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		msg, err := database.PostgresConnection.CancelPendingMessage(c.Request.Context(), tenantID(c), c.Param("id"), version)
+		if err != nil {
+			writePendingError(c, err, "failed to cancel message")
+			return
+		}
+		c.Header("ETag", msg.ETag())
+		c.JSON(http.StatusOK, gin.H{"message": "Message cancelled successfully", "data": msg})
+	}
+}
+
+// parseIfMatch returns the message version required by the If-Match header,
+// or 0 when any version is accepted.
+func parseIfMatch(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("If-Match must be the ETag of the message")
+	}
+	return version, nil
+}
+
+// writePendingError maps the errors of a conditional update of a pending message to a response.
+func writePendingError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, database.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+	case errors.Is(err, database.ErrMessageNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, database.ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure, "details": err.Error()})
+	}
+}
+
+// LookupMessagesHandler returns many messages merged with their delivery details.
+// @Summary      Look up messages
+// @Description  Returns the messages with the given IDs (at most 500) like GET /api/v1/messages/{id}, in the
+// @Description  order requested. Unknown IDs are listed under "missing".
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Security     ApiKey
+// @Param        payload  body      models.MessageLookupRequest  true  "message IDs"
+// @Success      200  {object} []models.MessageDetails  "Messages fetched successfully"
+// @Failure      400  "invalid request payload"
+// @Failure      500  "failed to fetch messages"
+// @Router       /api/v1/messages/lookup [post]
+func LookupMessagesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.MessageLookupRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if len(req.IDs) == 0 || len(req.IDs) > maxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ids must hold between 1 and %d IDs", maxPageLimit)})
+			return
+		}
+
+		// drop duplicates, keeping the requested order
+		seen := make(map[string]bool, len(req.IDs))
+		ids := make([]string, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+
+		details, err := lookupMessages(c.Request.Context(), tenantID(c), ids)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch messages", "details": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"message": "Messages fetched successfully", "data": msgs})
+
+		found := make(map[string]bool, len(details))
+		for _, d := range details {
+			found[d.ID] = true
+		}
+		missing := []string{}
+		for _, id := range ids {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Messages fetched successfully", "data": details, "missing": missing})
+	}
+}
+
+// lookupMessages returns the messages of the tenant among ids, in the same
+// order, merged with their Redis records. When Redis is unavailable the
+// details stored in Postgres are returned instead.
+func lookupMessages(ctx context.Context, tenantID string, ids []string) ([]models.MessageDetails, error) {
+	stored, err := database.PostgresConnection.FetchMessageDetails(ctx, tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := database.RedisClient.FetchRecords(ctx, ids)
+	if err != nil {
+		log.Logger.Warningf("falling back to Postgres delivery details: %v", err)
+		records = nil
+	}
+
+	details := make([]models.MessageDetails, 0, len(stored))
+	for _, id := range ids {
+		d, ok := stored[id]
+		if !ok {
+			continue
+		}
+		if rec, ok := records[id]; ok {
+			d.ProviderMessageID = rec.MessageID
+			d.SentAt = rec.SentAt
+			d.DetailsSource = models.DetailsSourceRedis
+		} else if d.ProviderMessageID != "" || d.SentAt != "" {
+			d.DetailsSource = models.DetailsSourcePostgres
+		}
+		details = append(details, d)
+	}
+	return details, nil
+}
+
+// ListAttemptsHandler returns every delivery attempt made for a message.
+// @Summary      List delivery attempts
+// @Description  Retrieves every provider request made for a message, including status, latency and response.
+// @Tags         Messages
+// @Produce      json
+// @Security     ApiKey
+// @Param        id   path      string  true  "Message ID"
+// @Success      200  {object} []models.DeliveryAttempt  "Delivery attempts fetched successfully"
+// @Failure      404   "message not found"
+// @Failure      500   "failed to fetch delivery attempts"
+// @Router       /api/v1/messages/{id}/attempts [get]
+func ListAttemptsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		attempts, err := database.PostgresConnection.FetchDeliveryAttempts(c.Request.Context(), tenantID(c), id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch delivery attempts", "details": err.Error()})
+			return
+		}
+
+		// no attempts may also mean an unknown message, or one of another tenant
+		if len(attempts) == 0 {
+			exists, err := database.PostgresConnection.MessageExists(c.Request.Context(), tenantID(c), id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch delivery attempts", "details": err.Error()})
+				return
+			}
+			if !exists {
+				c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Delivery attempts fetched successfully", "data": attempts})
 	}
 }
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	log.InitLogger()
+}
+
+// exportServer serves exportMessages backed by export behind Recovery, over a real connection.
+func exportServer(t *testing.T, export exportFunc) *httptest.Server {
+	t.Helper()
+	r := gin.New()
+	r.Use(Recovery())
+	r.GET("/export", exportMessages(export))
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExportMessagesComplete(t *testing.T) {
+	srv := exportServer(t, func(ctx context.Context, filter models.MessageFilter, emit func([]models.MessageDetails) error) error {
+		return emit([]models.MessageDetails{{ID: "m1", Content: "hi", PhoneNumber: "+905551111111"}})
+	})
+
+	resp, err := http.Get(srv.URL + "/export?format=csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading a complete export: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "id,content") || !strings.HasPrefix(lines[1], "m1,hi") {
+		t.Fatalf("unexpected export:\n%s", body)
+	}
+}
+
+func TestExportMessagesAbortedMidStream(t *testing.T) {
+	for _, format := range []string{"csv", "ndjson"} {
+		t.Run(format, func(t *testing.T) {
+			srv := exportServer(t, func(ctx context.Context, filter models.MessageFilter, emit func([]models.MessageDetails) error) error {
+				if err := emit([]models.MessageDetails{{ID: "m1"}}); err != nil {
+					return err
+				}
+				return errors.New("connection to postgres lost")
+			})
+
+			resp, err := http.Get(srv.URL + "/export?format=" + format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want the 200 already sent", resp.StatusCode)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err == nil {
+				t.Fatalf("truncated export read as complete:\n%s", body)
+			}
+			if !strings.Contains(string(body), "m1") {
+				t.Fatalf("rows sent before the failure are missing:\n%s", body)
+			}
+		})
+	}
+}
+
+func TestExportMessagesUnknownFormat(t *testing.T) {
+	srv := exportServer(t, func(ctx context.Context, filter models.MessageFilter, emit func([]models.MessageDetails) error) error {
+		t.Fatal("export must not run")
+		return nil
+	})
+
+	resp, err := http.Get(srv.URL + "/export?format=xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"*", 0, false},
+		{`"3"`, 3, false},
+		{`W/"3"`, 3, false},
+		{" 7 ", 7, false},
+		{`"0"`, 0, true},
+		{`"-1"`, 0, true},
+		{`"abc"`, 0, true},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPatch, "/messages/m1", nil)
+		if tt.header != "" {
+			c.Request.Header.Set("If-Match", tt.header)
+		}
+
+		got, err := parseIfMatch(c)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseIfMatch(%q) = %d, %v; want %d, wantErr %v", tt.header, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
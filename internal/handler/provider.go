@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"messaging-server/internal/ratelimit"
+	"net/http"
+)
+
+// ProviderRateHandler returns the current outbound send rate of every provider.
+// @Summary      Provider send rate
+// @Description  Returns the configured limit and the observed send rate of every provider.
+// @Tags         Providers
+// @Produce      json
+// @Security     ApiKey
+// @Success      200  {object} []models.ProviderRateStats  "Provider rates fetched successfully"
+// @Router       /api/v1/providers/rate [get]
+func ProviderRateHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "Provider rates fetched successfully", "data": ratelimit.Stats()})
+	}
+}
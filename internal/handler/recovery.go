@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	log "messaging-server/internal/logging"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recovery turns panics into 500 responses like gin.Recovery, except for
+// http.ErrAbortHandler: that one is passed on so net/http drops the connection
+// and a response that stopped half way is not completed as if it were whole.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			log.Logger.Errorf("panic serving %s %s: %v\n%s", c.Request.Method, c.Request.URL.Path, err, debug.Stack())
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		c.Next()
+	}
+}
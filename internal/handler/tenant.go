@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"net/http"
+)
+
+// TenantContextKey holds the ID of the tenant a request acts for; the
+// authentication middleware sets it.
+const TenantContextKey = "tenantID"
+
+// tenantID returns the tenant the request acts for, the default tenant when none was resolved.
+func tenantID(c *gin.Context) string {
+	if id := c.GetString(TenantContextKey); id != "" {
+		return id
+	}
+	return models.DefaultTenant
+}
+
+// CreateTenantHandler creates a tenant.
+// @Summary      Create tenant
+// @Description  Creates a tenant with its own messages and API keys. Messages of a tenant with a webhookUrl are
+// @Description  sent there instead of WEBHOOK_URL; a rateLimit (messages per second, 0 = unlimited) gives the
+// @Description  tenant its own token bucket instead of sharing PROVIDER_RATE_LIMIT.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        payload  body      models.CreateTenantRequest  true  "tenant ID and configuration"
+// @Success      201  {object} models.Tenant  "Tenant created successfully"
+// @Failure      400  "invalid tenant"
+// @Failure      401  "invalid or missing admin token"
+// @Failure      409  "tenant already exists"
+// @Failure      500  "failed to create tenant"
+// @Router       /api/v1/admin/tenants [post]
+func CreateTenantHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CreateTenantRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := req.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tenant, err := database.PostgresConnection.InsertTenant(c.Request.Context(), req.ID, req.TenantConfig)
+		if errors.Is(err, database.ErrTenantExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tenant", "details": err.Error()})
+			return
+		}
+
+		log.Logger.Infof("tenant %s created", tenant.ID)
+		c.JSON(http.StatusCreated, gin.H{"message": "Tenant created successfully", "data": tenant})
+	}
+}
+
+// ListTenantsHandler lists every tenant.
+// @Summary      List tenants
+// @Description  Lists every tenant with its provider configuration.
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object} []models.Tenant  "Tenants fetched successfully"
+// @Failure      401  "invalid or missing admin token"
+// @Failure      500  "failed to fetch tenants"
+// @Router       /api/v1/admin/tenants [get]
+func ListTenantsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenants, err := database.PostgresConnection.FetchTenants(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tenants", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Tenants fetched successfully", "data": tenants})
+	}
+}
+
+// UpdateTenantHandler replaces the configuration of a tenant.
+// @Summary      Update tenant
+// @Description  Replaces the name and provider configuration of a tenant; omitted provider settings fall back
+// @Description  to the deployment defaults. The send job picks the change up on its next run.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        id       path      string               true  "Tenant ID"
+// @Param        payload  body      models.TenantConfig  true  "tenant configuration"
+// @Success      200  {object} models.Tenant  "Tenant updated successfully"
+// @Failure      400  "invalid tenant"
+// @Failure      401  "invalid or missing admin token"
+// @Failure      404  "tenant not found"
+// @Failure      500  "failed to update tenant"
+// @Router       /api/v1/admin/tenants/{id} [put]
+func UpdateTenantHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var cfg models.TenantConfig
+		if err := c.ShouldBindJSON(&cfg); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+		if err := cfg.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tenant, err := database.PostgresConnection.UpdateTenant(c.Request.Context(), c.Param("id"), cfg)
+		if errors.Is(err, database.ErrTenantNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update tenant", "details": err.Error()})
+			return
+		}
+
+		log.Logger.Infof("tenant %s updated", tenant.ID)
+		c.JSON(http.StatusOK, gin.H{"message": "Tenant updated successfully", "data": tenant})
+	}
+}
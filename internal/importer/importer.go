@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"messaging-server/internal/database"
+	"messaging-server/internal/models"
+	"path/filepath"
+	"strings"
+)
+
+// Supported import formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// maxReportedErrors caps the rejected rows listed in a report; all of them are counted.
+const maxReportedErrors = 1000
+
+// maxLineSize is the longest NDJSON line accepted.
+const maxLineSize = 64 * 1024
+
+var (
+	// ErrUnknownFormat is returned for formats other than csv and ndjson.
+	ErrUnknownFormat = errors.New("format must be 'csv' or 'ndjson'")
+	// ErrInvalidUpload is returned when the upload as a whole can not be read.
+	ErrInvalidUpload = errors.New("invalid upload")
+)
+
+// row is a parsed row of an upload with its line number.
+type row struct {
+	line int
+	req  models.CreateMessageRequest
+	err  error // set when the row could not be parsed
+}
+
+// DetectFormat picks the format from an explicit value, a file name or a content type, in that order.
+func DetectFormat(format, filename, contentType string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return FormatCSV
+	case strings.HasPrefix(contentType, "application/x-ndjson"), strings.HasPrefix(contentType, "application/jsonl"):
+		return FormatNDJSON
+	}
+	return ""
+}
+
+// Import validates the rows of r and streams the valid ones into Postgres
+// with COPY, as messages of the tenant. Invalid rows are rejected and listed in the report; valid rows
+// are committed together, so an error returned here means nothing was stored.
+//
+// CSV uploads need a header naming the phone_number and content columns;
+// NDJSON uploads hold one {"phone_number": ..., "content": ...} object per line.
+func Import(ctx context.Context, tenantID string, r io.Reader, format string) (models.ImportReport, error) {
+	report := models.ImportReport{Format: format, Errors: []models.ImportRowError{}}
+
+	var next func() (row, error)
+	switch format {
+	case FormatCSV:
+		reader, err := newCSVReader(r)
+		if err != nil {
+			return report, err
+		}
+		next = reader
+	case FormatNDJSON:
+		next = newNDJSONReader(r)
+	default:
+		return report, ErrUnknownFormat
+	}
+
+	cp, err := database.PostgresConnection.BeginMessageCopy(ctx, tenantID)
+	if err != nil {
+		return report, err
+	}
+
+	for {
+		rw, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			cp.Rollback()
+			return report, err
+		}
+
+		report.Total++
+		if rw.err == nil {
+			rw.err = rw.req.Validate()
+		}
+		if rw.err != nil {
+			report.Rejected++
+			if len(report.Errors) < maxReportedErrors {
+				report.Errors = append(report.Errors, models.ImportRowError{Row: rw.line, Error: rw.err.Error()})
+			} else {
+				report.ErrorsTruncated = true
+			}
+			continue
+		}
+
+		if err := cp.Add(ctx, uuid.NewString(), rw.req.Content, rw.req.PhoneNumber); err != nil {
+			cp.Rollback()
+			return report, err
+		}
+	}
+
+	imported, err := cp.Commit(ctx)
+	if err != nil {
+		return report, err
+	}
+	report.Imported = imported
+	return report, nil
+}
+
+// newCSVReader reads the header of a CSV upload and returns a reader of its rows.
+func newCSVReader(r io.Reader) (func() (row, error), error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // rows with missing columns are rejected one by one
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: csv upload is empty", ErrInvalidUpload)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading csv header: %w", ErrInvalidUpload, err)
+	}
+
+	phoneCol, contentCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "phone_number":
+			phoneCol = i
+		case "content":
+			contentCol = i
+		}
+	}
+	if phoneCol < 0 || contentCol < 0 {
+		return nil, fmt.Errorf("%w: csv header must name the phone_number and content columns", ErrInvalidUpload)
+	}
+
+	return func() (row, error) {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return row{}, io.EOF
+		}
+
+		// a malformed row is rejected; the reader resumes on the next line
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return row{line: parseErr.StartLine, err: parseErr.Err}, nil
+		}
+		if err != nil {
+			return row{}, fmt.Errorf("reading csv: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) <= phoneCol || len(record) <= contentCol {
+			return row{line: line, err: fmt.Errorf("expected %d columns, got %d", len(header), len(record))}, nil
+		}
+		return row{line: line, req: models.CreateMessageRequest{
+			PhoneNumber: strings.TrimSpace(record[phoneCol]),
+			Content:     record[contentCol],
+		}}, nil
+	}, nil
+}
+
+// newNDJSONReader returns a reader of the rows of an NDJSON upload; blank lines are skipped.
+func newNDJSONReader(r io.Reader) func() (row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	line := 0
+
+	return func() (row, error) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+
+			var req models.CreateMessageRequest
+			if err := json.Unmarshal([]byte(text), &req); err != nil {
+				return row{line: line, err: fmt.Errorf("invalid JSON: %v", err)}, nil
+			}
+			return row{line: line, req: req}, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return row{}, fmt.Errorf("%w: reading ndjson line %d: %w", ErrInvalidUpload, line+1, err)
+		}
+		return row{}, io.EOF
+	}
+}
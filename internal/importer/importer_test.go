@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readAll drains a row reader.
+func readAll(t *testing.T, next func() (row, error)) []row {
+	t.Helper()
+	var rows []row
+	for {
+		rw, err := next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("reading rows: %v", err)
+		}
+		rows = append(rows, rw)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	upload := "content, Phone_Number\n" +
+		"Hello,+15551234567\n" +
+		"\"Hi, there\",  +15557654321\n" +
+		"missing column\n" +
+		"bad \"quote,+15550000000\n" +
+		"Bye,+15559876543\n"
+
+	next, err := newCSVReader(strings.NewReader(upload))
+	if err != nil {
+		t.Fatalf("newCSVReader: %v", err)
+	}
+	rows := readAll(t, next)
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want 5: %+v", len(rows), rows)
+	}
+
+	want := []struct {
+		line           int
+		content, phone string
+		wantErr        bool
+	}{
+		{2, "Hello", "+15551234567", false},
+		{3, "Hi, there", "+15557654321", false},
+		{4, "", "", true},
+		{5, "", "", true},
+		{6, "Bye", "+15559876543", false},
+	}
+	for i, w := range want {
+		rw := rows[i]
+		if rw.line != w.line || (rw.err != nil) != w.wantErr {
+			t.Errorf("row %d = line %d err %v, want line %d wantErr %v", i, rw.line, rw.err, w.line, w.wantErr)
+			continue
+		}
+		if !w.wantErr && (rw.req.Content != w.content || rw.req.PhoneNumber != w.phone) {
+			t.Errorf("row %d = %+v, want content %q phone %q", i, rw.req, w.content, w.phone)
+		}
+	}
+}
+
+func TestCSVReaderHeader(t *testing.T) {
+	for _, upload := range []string{"", "content,phone\nHello,+15551234567\n"} {
+		if _, err := newCSVReader(strings.NewReader(upload)); !errors.Is(err, ErrInvalidUpload) {
+			t.Errorf("newCSVReader(%q) = %v, want ErrInvalidUpload", upload, err)
+		}
+	}
+}
+
+func TestNDJSONReader(t *testing.T) {
+	upload := `{"phone_number": "+15551234567", "content": "Hello"}` + "\n" +
+		"\n" +
+		"   \n" +
+		`{"phone_number": "+15557654321"` + "\n" +
+		`{"phone_number": "+15559876543", "content": "Bye"}`
+
+	rows := readAll(t, newNDJSONReader(strings.NewReader(upload)))
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3: %+v", len(rows), rows)
+	}
+	if rows[0].line != 1 || rows[0].err != nil || rows[0].req.Content != "Hello" || rows[0].req.PhoneNumber != "+15551234567" {
+		t.Errorf("row 0 = %+v", rows[0])
+	}
+	if rows[1].line != 4 || rows[1].err == nil {
+		t.Errorf("row 1 = %+v, want an invalid JSON error on line 4", rows[1])
+	}
+	if rows[2].line != 5 || rows[2].err != nil || rows[2].req.Content != "Bye" {
+		t.Errorf("row 2 = %+v", rows[2])
+	}
+}
+
+func TestNDJSONReaderLineTooLong(t *testing.T) {
+	upload := `{"content": "` + strings.Repeat("a", maxLineSize) + `"}`
+	_, err := newNDJSONReader(strings.NewReader(upload))()
+	if !errors.Is(err, ErrInvalidUpload) {
+		t.Fatalf("reading an oversized line = %v, want ErrInvalidUpload", err)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		format, filename, contentType, want string
+	}{
+		{"CSV", "messages.ndjson", "", FormatCSV},
+		{"", "messages.csv", "application/x-ndjson", FormatCSV},
+		{"", "messages.jsonl", "", FormatNDJSON},
+		{"", "upload", "text/csv; charset=utf-8", FormatCSV},
+		{"", "upload", "application/x-ndjson", FormatNDJSON},
+		{"", "upload", "application/octet-stream", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.format, tt.filename, tt.contentType); got != tt.want {
+			t.Errorf("DetectFormat(%q, %q, %q) = %q, want %q", tt.format, tt.filename, tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/settings"
+	"sync"
+	"time"
+)
+
+// maxAdaptiveErrorRate is the share of failed sends in a batch above which
+// the adaptive batch size shrinks.
+const maxAdaptiveErrorRate = 0.1
+
+// batchController sizes the batches of the adaptive send mode. It grows the
+// batch size while the oldest pending message is older than BACKLOG_AGE_TARGET
+// and shrinks it when the provider slows down or fails. The size is kept
+// across runs and never drops below the fetch limit.
+type batchController struct {
+	mu  sync.Mutex
+	cur int
+}
+
+var adaptiveBatch = &batchController{}
+
+// size returns the batch size to claim next.
+func (b *batchController) size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if floor := settings.FetchLimit(); b.cur < floor {
+		b.cur = floor
+	}
+	return b.cur
+}
+
+// observe adjusts the batch size from the outcome of a batch and the age of the backlog.
+func (b *batchController) observe(ctx context.Context, stats batchStats) {
+	cfg := configs.AppConfig
+
+	attempts := stats.sent + stats.failed
+	if attempts == 0 {
+		return
+	}
+	errorRate := float64(stats.failed) / float64(attempts)
+	avgLatency := stats.latency / time.Duration(attempts)
+	latencyTarget := time.Duration(cfg.AdaptiveLatencyTarget) * time.Millisecond
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	prev := b.cur
+	floor := settings.FetchLimit()
+	switch {
+	// the provider is struggling; back off
+	case errorRate > maxAdaptiveErrorRate || avgLatency > latencyTarget:
+		b.cur /= 2
+		if b.cur < floor {
+			b.cur = floor
+		}
+		if b.cur != prev {
+			log.Logger.Infof("adaptive batch size shrunk from %d to %d (error rate %.2f, avg latency %s)",
+				prev, b.cur, errorRate, avgLatency)
+		}
+	default:
+		// the backlog is falling behind; speed up
+		age, err := database.PostgresConnection.OldestPendingAge(ctx)
+		if err != nil {
+			log.Logger.Warningf("failed to measure the backlog age: %v", err)
+			return
+		}
+		if age <= time.Duration(cfg.BacklogAgeTarget)*time.Second {
+			return
+		}
+		b.cur *= 2
+		if b.cur > cfg.AdaptiveMaxBatch {
+			b.cur = cfg.AdaptiveMaxBatch
+		}
+		if b.cur != prev {
+			log.Logger.Infof("adaptive batch size grown from %d to %d (backlog age %s)", prev, b.cur, age.Round(time.Second))
+		}
+	}
+}
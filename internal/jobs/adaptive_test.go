@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"context"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/settings"
+	"testing"
+	"time"
+)
+
+func init() {
+	log.InitLogger()
+}
+
+func TestBatchControllerSizeNeverBelowFetchLimit(t *testing.T) {
+	b := &batchController{}
+	if got, want := b.size(), settings.FetchLimit(); got != want {
+		t.Fatalf("size() = %d, want the fetch limit %d", got, want)
+	}
+}
+
+func TestBatchControllerShrinks(t *testing.T) {
+	floor := settings.FetchLimit()
+	tests := []struct {
+		name  string
+		stats batchStats
+	}{
+		{"error rate", batchStats{sent: 5, failed: 5}},
+		{"latency", batchStats{sent: 10, latency: 10 * time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &batchController{cur: floor * 8}
+			b.observe(context.Background(), tt.stats)
+			if got, want := b.size(), floor*4; got != want {
+				t.Fatalf("size() = %d, want %d", got, want)
+			}
+
+			// shrinking stops at the fetch limit
+			for i := 0; i < 10; i++ {
+				b.observe(context.Background(), tt.stats)
+			}
+			if got := b.size(); got != floor {
+				t.Fatalf("size() = %d, want the fetch limit %d", got, floor)
+			}
+		})
+	}
+}
+
+func TestBatchControllerIgnoresEmptyBatches(t *testing.T) {
+	b := &batchController{cur: 64}
+	b.observe(context.Background(), batchStats{})
+	if got := b.size(); got != 64 {
+		t.Fatalf("size() = %d, want 64", got)
+	}
+}
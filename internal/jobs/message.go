@@ -2,19 +2,106 @@ package jobs
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"messaging-server/internal/configs"
+	"messaging-server/internal/cron"
 	"messaging-server/internal/database"
+	"messaging-server/internal/events"
 	log "messaging-server/internal/logging"
 	"messaging-server/internal/models"
+	"messaging-server/internal/settings"
+	pkgUtils "messaging-server/pkg/utils"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
 	"time"
 )
 
-// sendViaAPI serializes the payload and posts it to your external URL
-func sendViaAPI(client *http.Client, msg models.Message) ([]byte, error) {
+// defaultRetryAfter is used when a provider answers 429 without a usable Retry-After header.
+const defaultRetryAfter = 5 * time.Second
+
+// errNotSent marks a failed send that never reached the provider.
+var errNotSent = errors.New("request not sent")
+
+// rateLimitedError is returned by sendViaAPI when the provider answered 429.
+type rateLimitedError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("provider rate limit exceeded, retry after %s", e.retryAfter)
+}
+
+// providerName identifies the provider behind a webhook URL.
+func providerName(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+	return u.Host
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return defaultRetryAfter
+}
+
+// classifyError maps a failed request to the error class stored with its delivery attempt.
+func classifyError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.AttemptErrorTimeout
+	case errors.As(err, &netErr):
+		return models.AttemptErrorNetwork
+	default:
+		return models.AttemptErrorRequest
+	}
+}
+
+// classifyStatus maps an unexpected HTTP status to the error class stored with its delivery attempt.
+func classifyStatus(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return models.AttemptErrorRateLimited
+	case status >= 500:
+		return models.AttemptErrorServer
+	case status >= 400:
+		return models.AttemptErrorClient
+	default:
+		return models.AttemptErrorUnexpected
+	}
+}
+
+// truncateBody shortens a response body to what is stored with a delivery
+// attempt, cutting on a character boundary and replacing what Postgres text can
+// not hold, so the attempt is not rejected.
+func truncateBody(body []byte) string {
+	return pkgUtils.SanitizeText(pkgUtils.TruncateUTF8(string(body), models.MaxAttemptBodySize))
+}
+
+// sendViaAPI serializes the payload and posts it to your external URL,
+// filling attempt with what happened on the wire.
+func sendViaAPI(ctx context.Context, client *http.Client, endpoint string, msg models.Message, attempt *models.DeliveryAttempt) ([]byte, error) {
+	attempt.MessageID = msg.ID
+	attempt.Endpoint = endpoint
+	attempt.IdempotencyKey = msg.IdempotencyKey()
+	attempt.RequestedAt = time.Now()
+
 	// build JSON body
 	body, err := json.Marshal(
 		models.SendMessage{
@@ -22,70 +109,248 @@ func sendViaAPI(client *http.Client, msg models.Message) ([]byte, error) {
 			To:      msg.PhoneNumber,
 		})
 	if err != nil {
-		return nil, fmt.Errorf("json.Marshal failed: %w", err)
+		attempt.ErrorClass = models.AttemptErrorRequest
+		return nil, fmt.Errorf("%w: json.Marshal failed: %w", errNotSent, err)
 	}
 
 	// create request body and header
-	req, err := http.NewRequest(http.MethodPost, configs.AppConfig.WebhookURL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(body))
 	if err != nil {
-		return nil, fmt.Errorf("creating request failed: %w", err)
+		attempt.ErrorClass = models.AttemptErrorRequest
+		return nil, fmt.Errorf("%w: creating request failed: %w", errNotSent, err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", msg.IdempotencyKey())
 
 	// send the request
 	resp, err := client.Do(req)
 	if err != nil {
+		attempt.LatencyMs = time.Since(attempt.RequestedAt).Milliseconds()
+		attempt.ErrorClass = classifyError(err)
+		// a connection that could not be established carried no request
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return nil, fmt.Errorf("%w: error when sending the request: %w", errNotSent, err)
+		}
 		return nil, fmt.Errorf("error when sending the request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// read the response body
 	respBody, _ := io.ReadAll(resp.Body)
+	attempt.LatencyMs = time.Since(attempt.RequestedAt).Milliseconds()
+	attempt.HTTPStatus = resp.StatusCode
+	attempt.ResponseBody = truncateBody(respBody)
+
+	// the provider is throttling us
+	if resp.StatusCode == http.StatusTooManyRequests {
+		attempt.ErrorClass = models.AttemptErrorRateLimited
+		return nil, &rateLimitedError{retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
 
 	// check for not accepted status codes
 	if resp.StatusCode != http.StatusAccepted {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+		attempt.ErrorClass = classifyStatus(resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, attempt.ResponseBody)
 	}
 
+	attempt.ErrorClass = models.AttemptErrorNone
 	return respBody, nil
 }
 
-// processMessage logs a single message and marks it as sent
-func processMessage(client *http.Client, msg models.Message) {
+// recordAttempt stores a delivery attempt; failures are only logged.
+func recordAttempt(ctx context.Context, attempt *models.DeliveryAttempt) {
+	if err := database.PostgresConnection.InsertDeliveryAttempt(ctx, attempt); err != nil {
+		log.Logger.Errorf("failed to record delivery attempt of message %s: %v", attempt.MessageID, err)
+	}
+}
+
+// undelivered reports whether a failed send certainly did not deliver the
+// message: the request never reached the provider or the provider refused it
+// with a 4xx. Timeouts, transport errors once the request was written and 5xx
+// answers may still have been accepted.
+func undelivered(err error, attempt models.DeliveryAttempt) bool {
+	if errors.Is(err, errNotSent) {
+		return true
+	}
+	return attempt.HTTPStatus >= 400 && attempt.HTTPStatus < 500
+}
+
+// releaseMessage puts a claimed message back to pending so the next run retries it
+func releaseMessage(ctx context.Context, msg models.Message) {
+	if err := database.PostgresConnection.ReleaseMessage(ctx, msg.ID); err != nil {
+		log.Logger.Errorf("failed to release message %s: %v", msg.ID, err)
+	}
+}
+
+// publishEvent pushes a lifecycle event of msg to the event stream subscribers.
+func publishEvent(status string, msg models.Message, providerMessageID string, err error) {
+	ev := models.MessageEvent{
+		Status:            status,
+		MessageID:         msg.ID,
+		TenantID:          msg.TenantID,
+		PhoneNumber:       msg.PhoneNumber,
+		ProviderMessageID: providerMessageID,
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	events.Publish(ev)
+}
+
+// processMessage sends a single claimed message and records its outcome.
+// Only messages the provider certainly did not accept are released back to
+// pending; a message whose outcome is unknown stays sending for the
+// reconciliation, so it can not be edited into a send with a new idempotency
+// key. The Redis projection happens asynchronously from the outbox. It reports
+// whether the provider accepted the message, how long the provider request
+// took and the error to record with the run, if any.
+//
+// The provider request and the bookkeeping use a context detached from ctx's
+// cancellation, so a started send completes and its outcome is recorded even
+// when the run is cancelled.
+func processMessage(ctx context.Context, client *http.Client, prov provider, msg models.Message) (bool, time.Duration, error) {
+
+	bookkeeping := context.WithoutCancel(ctx)
+
+	log.Logger.Debugf("processing message id=%s to=%s key=%s", msg.ID, msg.PhoneNumber, msg.IdempotencyKey())
 
-	log.Logger.Debugf("processing message id=%s to=%s", msg.ID, msg.PhoneNumber)
+	// wait for the provider's throughput budget
+	if err := prov.limiter.Wait(ctx); err != nil {
+		log.Logger.Errorf("rate limiter wait aborted for message id=%s: %v", msg.ID, err)
+		releaseMessage(bookkeeping, msg)
+		publishEvent(models.EventFailed, msg, "", err)
+		return false, 0, err
+	}
 
 	// calculate the sending time
 	sendingTime := time.Now().Format(time.RFC3339)
 
-	respBody, err := sendViaAPI(client, msg)
+	// a request in flight may already have been accepted, so cancelling the run
+	// (timeout, CancelRuns, lost leadership) does not abort it; the client's
+	// timeout bounds it and the batch checks for cancellation between messages
+	var attempt models.DeliveryAttempt
+	respBody, err := sendViaAPI(bookkeeping, client, prov.endpoint, msg, &attempt)
+	latency := time.Duration(attempt.LatencyMs) * time.Millisecond
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	recordAttempt(bookkeeping, &attempt)
 	if err != nil {
+		var rateErr *rateLimitedError
+		if errors.As(err, &rateErr) {
+			prov.limiter.Pause(rateErr.retryAfter)
+		}
 		log.Logger.Errorf("failed to send message id=%s: %v", msg.ID, err)
-		return
+		if undelivered(err, attempt) {
+			releaseMessage(bookkeeping, msg)
+		} else {
+			log.Logger.Warningf("provider may have accepted message id=%s; it stays sending for the reconciliation", msg.ID)
+		}
+		publishEvent(models.EventFailed, msg, "", err)
+		return false, latency, err
 	}
 	log.Logger.Debugf("message sent successfully at %s", sendingTime)
 
-	// create a RedisRecord
+	// create a RedisRecord; the provider accepted the message even if its body is unreadable
 	var redisRecord models.RedisRecord
 	if err = json.Unmarshal(respBody, &redisRecord); err != nil {
 		log.Logger.Errorf("failed to parse response JSON: %v; body=%s", err, string(respBody))
-		return
 	}
+	redisRecord.ID = msg.ID
 	redisRecord.SentAt = sendingTime
+	redisRecord.IdempotencyKey = msg.IdempotencyKey()
+
+	// the message stays claimed if this fails; the reconciliation picks it up
+	err = database.PostgresConnection.MarkSent(bookkeeping, redisRecord)
+	if err != nil {
+		log.Logger.Errorf("failed to mark message %s as sent (provider id %q): %v", msg.ID, redisRecord.MessageID, err)
+	}
+	publishEvent(models.EventSent, msg, redisRecord.MessageID, nil)
+	return true, latency, err
+}
+
+// batchStats summarizes the outcome of one claimed batch.
+type batchStats struct {
+	claimed int
+	sent    int
+	failed  int
+	latency time.Duration // total latency of the provider requests
+}
+
+// sendBatch claims up to limit messages and sends them. The messages of each
+// tenant are sent one by one, concurrently with those of the other tenants, so
+// a tenant waiting for its rate limit does not hold up the rest of the batch.
+// When ctx is cancelled, the messages not sent yet are released back to pending.
+func sendBatch(ctx context.Context, client *http.Client, providers *tenantProviders, limit int) (batchStats, error) {
 
-	//time.Sleep(3 * time.Second) // simulate processing delay
+	run := cron.RunFromContext(ctx)
+
+	var stats batchStats
+	messages, err := database.PostgresConnection.ClaimPendingMessages(ctx, limit, run.FencingToken)
+	if err != nil {
+		return stats, fmt.Errorf("failed to claim pending messages: %w", err)
+	}
+	stats.claimed = len(messages)
+	run.Fetched += len(messages)
 
-	if err = database.RedisClient.InsertRecord(redisRecord); err != nil {
-		log.Logger.Errorf("failed to insert record into Redis: %v", err)
+	// group the messages by tenant, keeping the claim order within each tenant
+	var tenants []string
+	byTenant := map[string][]models.Message{}
+	for _, msg := range messages {
+		publishEvent(models.EventClaimed, msg, "", nil)
+		if _, ok := byTenant[msg.TenantID]; !ok {
+			tenants = append(tenants, msg.TenantID)
+		}
+		byTenant[msg.TenantID] = append(byTenant[msg.TenantID], msg)
 	}
 
-	if err = database.PostgresConnection.MarkSent(msg.ID); err != nil {
-		log.Logger.Errorf("failed to mark message %s as sent: %v", msg.ID, err)
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		aborted error
+	)
+	for _, tenant := range tenants {
+		wg.Add(1)
+		go func(prov provider, messages []models.Message) {
+			defer wg.Done()
+			for i, msg := range messages {
+				if err := ctx.Err(); err != nil {
+					for _, rest := range messages[i:] {
+						releaseMessage(context.WithoutCancel(ctx), rest)
+					}
+					mu.Lock()
+					aborted = err
+					mu.Unlock()
+					return
+				}
+				sent, latency, err := processMessage(ctx, client, prov, msg)
+
+				mu.Lock()
+				if sent {
+					stats.sent++
+					run.Sent++
+				} else {
+					stats.failed++
+					run.Failed++
+				}
+				if err != nil {
+					run.RecordError(fmt.Errorf("message %s: %w", msg.ID, err))
+				}
+				stats.latency += latency
+				mu.Unlock()
+			}
+		}(providers.forTenant(tenant), byTenant[tenant])
 	}
+	wg.Wait()
+	return stats, aborted
 }
 
-// SendMessageJob pulls up to FetchLimit messages, logs them, and marks them sent
-func SendMessageJob() {
+// SendMessageJob claims up to the runtime fetch limit of messages, or the
+// run's fetch limit override, sends them, and marks them sent. In the drain
+// and adaptive send modes it keeps claiming batches until the queue is empty
+// or SEND_TIME_BUDGET is exhausted.
+func SendMessageJob(ctx context.Context) error {
 
 	// create a per-job HTTP client with its own Transport
 	transport := &http.Transport{}
@@ -97,18 +362,69 @@ func SendMessageJob() {
 	// close idle connections when the job is done
 	defer transport.CloseIdleConnections()
 
-	messages, err := database.PostgresConnection.FetchPendingMessages(configs.AppConfig.MessageFetchLimit)
+	// every tenant sends through its own provider settings
+	providers, err := loadTenantProviders(ctx)
 	if err != nil {
-		log.Logger.Errorf("failed to fetch pending messages: %v", err)
-		return
+		return err
+	}
+
+	// a manual run may claim a one-off number of messages
+	limit := settings.FetchLimit()
+	override := cron.OverridesFromContext(ctx).FetchLimit
+	if override > 0 {
+		limit = override
+	}
+
+	switch configs.AppConfig.SendMode {
+	case models.SendModeDrain:
+		return drainQueue(ctx, client, providers, func() int { return limit }, nil)
+	case models.SendModeAdaptive:
+		if override > 0 {
+			return drainQueue(ctx, client, providers, func() int { return limit }, nil)
+		}
+		return drainQueue(ctx, client, providers, adaptiveBatch.size, adaptiveBatch.observe)
 	}
-	if len(messages) == 0 {
+
+	stats, err := sendBatch(ctx, client, providers, limit)
+	if err == nil && stats.claimed == 0 {
 		log.Logger.Info("no pending messages to process")
-		return
 	}
+	return err
+}
 
-	for _, msg := range messages {
-		processMessage(client, msg)
-		log.Logger.Debug("Message processed successfully")
+// drainQueue sends batches of batchSize messages until the queue is empty,
+// the time budget is exhausted or a whole batch fails. observe, when set, is
+// told the outcome of every batch.
+func drainQueue(ctx context.Context, client *http.Client, providers *tenantProviders,
+	batchSize func() int, observe func(ctx context.Context, stats batchStats)) error {
+
+	budget := time.Duration(configs.AppConfig.SendTimeBudget) * time.Second
+	deadline := time.Now().Add(budget)
+
+	for batches := 1; ; batches++ {
+		stats, err := sendBatch(ctx, client, providers, batchSize())
+		if err != nil {
+			return err
+		}
+		if stats.claimed == 0 {
+			if batches == 1 {
+				log.Logger.Info("no pending messages to process")
+			} else {
+				log.Logger.Infof("queue drained after %d batches", batches-1)
+			}
+			return nil
+		}
+		if observe != nil {
+			observe(ctx, stats)
+		}
+		// released messages would be claimed again right away
+		if stats.sent == 0 {
+			log.Logger.Warningf("no message of batch %d was sent; stopping the drain", batches)
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			log.Logger.Infof("send time budget of %s exhausted after %d batches", budget, batches)
+			return nil
+		}
 	}
 }
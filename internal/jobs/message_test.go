@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30", 30 * time.Second},
+		{"1", time.Second},
+		{"0", defaultRetryAfter},
+		{"-5", defaultRetryAfter},
+		{"", defaultRetryAfter},
+		{"soon", defaultRetryAfter},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), defaultRetryAfter},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfterHTTPDate(t *testing.T) {
+	value := time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat)
+	got := parseRetryAfter(value)
+	// HTTP dates have a resolution of one second
+	if got <= time.Minute || got > 2*time.Minute {
+		t.Fatalf("parseRetryAfter(%q) = %s, want about 2m", value, got)
+	}
+}
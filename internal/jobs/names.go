@@ -0,0 +1,9 @@
+package jobs
+
+// Names the jobs are registered under in the scheduler.
+const (
+	SendJobName      = "send"
+	ProjectJobName   = "project"
+	ReconcileJobName = "reconcile"
+	PurgeJobName     = "purge"
+)
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"messaging-server/internal/cron"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+)
+
+// projectorBatchSize is the number of outbox records projected per run.
+const projectorBatchSize = 100
+
+// ProjectSentRecordsJob copies committed sent outcomes from the Postgres
+// outbox into Redis. Failed projections stay in the outbox and are retried
+// with an exponential backoff.
+func ProjectSentRecordsJob(ctx context.Context) error {
+
+	run := cron.RunFromContext(ctx)
+
+	records, err := database.PostgresConnection.FetchOutbox(ctx, projectorBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch redis outbox: %w", err)
+	}
+	run.Fetched = len(records)
+
+	for _, rec := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := database.RedisClient.InsertRecord(ctx, rec); err != nil {
+			log.Logger.Warningf("failed to project message %s into Redis, will retry: %v", rec.ID, err)
+			run.Failed++
+			run.RecordError(err)
+			if err := database.PostgresConnection.DeferOutbox(ctx, rec.ID, err); err != nil {
+				log.Logger.Errorf("failed to defer outbox record: %v", err)
+			}
+			continue
+		}
+
+		run.Sent++
+		if err := database.PostgresConnection.DeleteOutbox(ctx, rec.ID); err != nil {
+			log.Logger.Errorf("failed to delete outbox record: %v", err)
+			run.RecordError(err)
+		}
+	}
+
+	if len(records) > 0 {
+		log.Logger.Debugf("Projected %d of %d sent records into Redis", run.Sent, len(records))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/cron"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+)
+
+// purgeBatchSize is the number of job runs deleted per statement.
+const purgeBatchSize = 5000
+
+// PurgeJobRunsJob deletes the job history older than JOB_RUN_RETENTION.
+// The run's fetched counter holds the number of runs deleted.
+func PurgeJobRunsJob(ctx context.Context) error {
+
+	run := cron.RunFromContext(ctx)
+
+	deleted, err := database.PostgresConnection.PurgeJobRuns(ctx, configs.AppConfig.JobRunRetention, purgeBatchSize)
+	run.Fetched = int(deleted)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Logger.Infof("purged %d job runs older than %ds", deleted, configs.AppConfig.JobRunRetention)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/cron"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"sync"
+	"time"
+)
+
+// reconcileTTLMargin keeps records close to their Redis expiry out of the
+// Postgres-only check, so keys expiring during the run are not reported.
+const reconcileTTLMargin = 60
+
+var (
+	reportMu     sync.RWMutex
+	latestReport *models.ReconciliationReport
+)
+
+// LatestReconciliationReport returns the report of the last reconciliation run, or nil.
+func LatestReconciliationReport() *models.ReconciliationReport {
+	reportMu.RLock()
+	defer reportMu.RUnlock()
+	return latestReport
+}
+
+// ReconcileJob compares sent outcomes in Redis and Postgres and repairs the
+// drift according to RECONCILE_POLICY:
+//   - Redis has a record but Postgres is not sent: the provider accepted the
+//     message, so Postgres is marked as sent.
+//   - Postgres is sent but Redis has no record: the projection is queued again.
+//   - Messages stuck in sending without a Redis record are released back to
+//     pending unless a delivery attempt succeeded; those are only reported,
+//     since the provider accepted them without the outcome being stored.
+//
+// The run's fetched counter holds the number of drifted messages found.
+func ReconcileJob(ctx context.Context) error {
+
+	run := cron.RunFromContext(ctx)
+
+	report := &models.ReconciliationReport{
+		StartedAt:    time.Now().Format(time.RFC3339),
+		Policy:       configs.AppConfig.ReconcilePolicy,
+		RedisOnly:    []models.DriftEntry{},
+		PostgresOnly: []models.DriftEntry{},
+		StaleClaims:  []models.DriftEntry{},
+	}
+	repair := report.Policy == models.ReconcilePolicyRepair
+
+	redisRecords, err := database.RedisClient.ScanSentRecords(ctx)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		publishReport(report)
+		return fmt.Errorf("reconciliation: failed to scan Redis: %w", err)
+	}
+
+	// Redis has a record but Postgres still says unsent
+	ids := make([]string, 0, len(redisRecords))
+	for id := range redisRecords {
+		ids = append(ids, id)
+	}
+	unsent, err := database.PostgresConnection.FetchUnsentByIDs(ctx, ids)
+	if err != nil {
+		log.Logger.Errorf("reconciliation: %v", err)
+		report.Errors = append(report.Errors, err.Error())
+	}
+	for id, status := range unsent {
+		rec := redisRecords[id]
+		entry := models.DriftEntry{ID: id, Status: status, ProviderMessageID: rec.MessageID, SentAt: rec.SentAt, Action: "none"}
+		if repair {
+			marked, err := database.PostgresConnection.ReconcileSent(ctx, rec)
+			switch {
+			case err != nil:
+				report.Errors = append(report.Errors, err.Error())
+			case marked:
+				entry.Action = "marked_sent"
+				report.Repaired++
+			default:
+				// e.g. cancelled after the provider accepted it
+				entry.Action = "manual_review"
+			}
+		}
+		report.RedisOnly = append(report.RedisOnly, entry)
+	}
+
+	// Postgres says sent but Redis has no record
+	if window := configs.RedisConfig.TTL - reconcileTTLMargin; window > 0 {
+		sent, err := database.PostgresConnection.FetchUnprojectedSent(ctx, window)
+		if err != nil {
+			log.Logger.Errorf("reconciliation: %v", err)
+			report.Errors = append(report.Errors, err.Error())
+		}
+		for _, rec := range sent {
+			if _, ok := redisRecords[rec.ID]; ok {
+				continue
+			}
+			entry := models.DriftEntry{ID: rec.ID, Status: models.StatusSent, ProviderMessageID: rec.MessageID, SentAt: rec.SentAt, Action: "none"}
+			if repair {
+				if err := database.PostgresConnection.RequeueOutbox(ctx, rec); err != nil {
+					report.Errors = append(report.Errors, err.Error())
+				} else {
+					entry.Action = "requeued_projection"
+					report.Repaired++
+				}
+			}
+			report.PostgresOnly = append(report.PostgresOnly, entry)
+		}
+	}
+
+	// claims that never completed and have no provider outcome in Redis
+	stale, err := database.PostgresConnection.FetchStaleClaims(ctx, configs.AppConfig.StaleClaimAfter)
+	if err != nil {
+		log.Logger.Errorf("reconciliation: %v", err)
+		report.Errors = append(report.Errors, err.Error())
+	}
+	for _, entry := range stale {
+		if _, ok := redisRecords[entry.ID]; ok {
+			continue
+		}
+		entry.Action = "manual_review"
+		if repair {
+			released, err := database.PostgresConnection.ReleaseStaleClaim(ctx, entry.ID, configs.AppConfig.StaleClaimAfter)
+			if err != nil {
+				report.Errors = append(report.Errors, err.Error())
+			} else if released {
+				entry.Action = "released"
+				report.Repaired++
+			}
+		}
+		report.StaleClaims = append(report.StaleClaims, entry)
+	}
+
+	publishReport(report)
+	run.Fetched = len(report.RedisOnly) + len(report.PostgresOnly) + len(report.StaleClaims)
+	for _, e := range report.Errors {
+		run.RecordError(errors.New(e))
+	}
+	log.Logger.Infof("reconciliation finished: %d redis-only, %d postgres-only, %d stale claims, %d repaired",
+		len(report.RedisOnly), len(report.PostgresOnly), len(report.StaleClaims), report.Repaired)
+	return ctx.Err()
+}
+
+// publishReport stamps the report and makes it the latest one.
+func publishReport(report *models.ReconciliationReport) {
+	report.FinishedAt = time.Now().Format(time.RFC3339)
+
+	reportMu.Lock()
+	latestReport = report
+	reportMu.Unlock()
+}
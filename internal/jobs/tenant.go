@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/database"
+	"messaging-server/internal/models"
+	"messaging-server/internal/ratelimit"
+)
+
+// provider is where and how fast the messages of a tenant are sent.
+type provider struct {
+	endpoint string
+	limiter  *ratelimit.Limiter
+}
+
+// tenantProviders resolves the provider of every tenant for one run of the send job.
+type tenantProviders struct {
+	tenants  map[string]models.Tenant
+	resolved map[string]provider
+}
+
+// loadTenantProviders reads the tenant configuration in effect for a run, so
+// changes made through the admin API apply from the next run on.
+func loadTenantProviders(ctx context.Context) (*tenantProviders, error) {
+	tenants, err := database.PostgresConnection.FetchTenants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenants: %w", err)
+	}
+	p := &tenantProviders{
+		tenants:  make(map[string]models.Tenant, len(tenants)),
+		resolved: make(map[string]provider, len(tenants)),
+	}
+	for _, t := range tenants {
+		p.tenants[t.ID] = t
+	}
+	return p, nil
+}
+
+// forTenant returns the provider of the tenant. Tenants without a webhook URL
+// send through WEBHOOK_URL; tenants without a rate limit share the limit of
+// their provider.
+func (p *tenantProviders) forTenant(id string) provider {
+	if pr, ok := p.resolved[id]; ok {
+		return pr
+	}
+
+	t := p.tenants[id]
+	pr := provider{endpoint: configs.AppConfig.WebhookURL}
+	if t.WebhookURL != "" {
+		pr.endpoint = t.WebhookURL
+	}
+	if t.RateLimit != nil {
+		burst := 0
+		if t.RateBurst != nil {
+			burst = *t.RateBurst
+		}
+		pr.limiter = ratelimit.ForTenant(providerName(pr.endpoint), id, *t.RateLimit, burst)
+	} else {
+		pr.limiter = ratelimit.ForProvider(providerName(pr.endpoint))
+	}
+
+	p.resolved[id] = pr
+	return pr
+}
@@ -0,0 +1,194 @@
+package leader
+
+import (
+	"context"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"sync"
+	"time"
+)
+
+// leaseName is the Redis lease the scheduler replicas compete for.
+const leaseName = "scheduler"
+
+// Elector elects a single leader among the replicas through a Redis lease.
+// Each acquisition hands out a new, strictly increasing fencing token, so
+// writes made by a leader that lost its lease can be rejected.
+//
+// When disabled, the instance always considers itself the leader.
+type Elector struct {
+	enabled    bool
+	instanceID string
+	ttl        time.Duration
+
+	mu         sync.RWMutex
+	leader     bool
+	token      int64
+	leaseUntil time.Time
+	onChange   func(isLeader bool)
+}
+
+// NewElector returns an Elector for instanceID holding leases for ttl.
+func NewElector(enabled bool, instanceID string, ttl time.Duration) *Elector {
+	return &Elector{
+		enabled:    enabled,
+		instanceID: instanceID,
+		ttl:        ttl,
+	}
+}
+
+// OnChange registers a callback invoked whenever leadership is gained or lost.
+func (e *Elector) OnChange(fn func(isLeader bool)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onChange = fn
+}
+
+// IsLeader reports whether this instance currently holds the lease.
+func (e *Elector) IsLeader() bool {
+	if !e.enabled {
+		return true
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	// a lease that could not be renewed in time is no longer ours
+	return e.leader && time.Now().Before(e.leaseUntil)
+}
+
+// FencingToken returns the token of the current lease, or 0 when not leading
+// or when leader election is disabled.
+func (e *Elector) FencingToken() int64 {
+	if !e.enabled || !e.IsLeader() {
+		return 0
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.token
+}
+
+// InstanceID returns the ID this instance competes with.
+func (e *Elector) InstanceID() string {
+	return e.instanceID
+}
+
+// Status returns the election state, including the current leader.
+func (e *Elector) Status(ctx context.Context) models.LeaderStatus {
+	status := models.LeaderStatus{
+		Enabled:    e.enabled,
+		InstanceID: e.instanceID,
+		IsLeader:   e.IsLeader(),
+	}
+	if !e.enabled {
+		status.Leader = e.instanceID
+		return status
+	}
+
+	holder, token, err := database.RedisClient.LeaseHolder(ctx, leaseName)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Leader = holder
+	status.FencingToken = token
+	return status
+}
+
+// Run competes for the lease and renews it until ctx is done, then releases it.
+func (e *Elector) Run(ctx context.Context) {
+	if !e.enabled {
+		return
+	}
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	e.tick(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			e.tick(ctx)
+		case <-ctx.Done():
+			e.release()
+			return
+		}
+	}
+}
+
+// tick renews the lease when leading and tries to acquire it otherwise.
+func (e *Elector) tick(ctx context.Context) {
+	e.mu.RLock()
+	leading, token := e.leader, e.token
+	e.mu.RUnlock()
+
+	now := time.Now()
+
+	if leading {
+		renewed, err := database.RedisClient.RenewLease(ctx, leaseName, e.instanceID, token, e.ttl)
+		switch {
+		case err != nil:
+			log.Logger.Warningf("failed to renew leader lease: %v", err)
+			// keep leading until the lease we hold expires
+			e.mu.RLock()
+			expired := !now.Before(e.leaseUntil)
+			e.mu.RUnlock()
+			if expired {
+				e.setLeader(false, 0, time.Time{})
+			}
+		case !renewed:
+			e.setLeader(false, 0, time.Time{})
+		default:
+			e.mu.Lock()
+			e.leaseUntil = now.Add(e.ttl)
+			e.mu.Unlock()
+		}
+		return
+	}
+
+	token, err := database.RedisClient.AcquireLease(ctx, leaseName, e.instanceID, e.ttl)
+	if err != nil {
+		log.Logger.Warningf("failed to acquire leader lease: %v", err)
+		return
+	}
+	if token > 0 {
+		e.setLeader(true, token, now.Add(e.ttl))
+	}
+}
+
+// release gives up the lease so another replica can take over immediately.
+func (e *Elector) release() {
+	e.mu.RLock()
+	leading, token := e.leader, e.token
+	e.mu.RUnlock()
+	if !leading {
+		return
+	}
+
+	if err := database.RedisClient.ReleaseLease(context.Background(), leaseName, e.instanceID, token); err != nil {
+		log.Logger.Warningf("failed to release leader lease: %v", err)
+	}
+	e.setLeader(false, 0, time.Time{})
+}
+
+// setLeader updates the leadership state and notifies the callback on changes.
+func (e *Elector) setLeader(leader bool, token int64, leaseUntil time.Time) {
+	e.mu.Lock()
+	changed := e.leader != leader
+	e.leader, e.token, e.leaseUntil = leader, token, leaseUntil
+	onChange := e.onChange
+	e.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if leader {
+		log.Logger.Infof("instance %s became leader with fencing token %d", e.instanceID, token)
+	} else {
+		log.Logger.Warningf("instance %s lost leadership", e.instanceID)
+	}
+	if onChange != nil {
+		onChange(leader)
+	}
+}
@@ -1,9 +1,98 @@
 package models
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxContentLength is the longest message content the messages table holds.
+const MaxContentLength = 255
+
+// phoneNumberPattern matches E.164 phone numbers.
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
 // CronRequest models the incoming JSON body for cron control.
+// Job defaults to the send job when empty. Mode applies to "stop" and is
+// either "drain" (default) or "cancel". Overrides apply to "run" only.
 type CronRequest struct {
-	Action string `json:"action"`
+	Job       string       `json:"job"`
+	Action    string       `json:"action"`
+	Mode      string       `json:"mode"`
+	Overrides RunOverrides `json:"overrides"`
+}
+
+// RunOverrides replaces configuration values for a single run; zero values keep the configuration.
+type RunOverrides struct {
+	FetchLimit int `json:"fetchLimit"`
+}
+
+// CreateMessageRequest models the incoming JSON body enqueuing a message.
+type CreateMessageRequest struct {
+	Content     string `json:"content"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+// Validate checks the message fits the messages table and the phone number is in E.164 format.
+func (r CreateMessageRequest) Validate() error {
+	if err := validateContent(r.Content); err != nil {
+		return err
+	}
+	return validatePhoneNumber(r.PhoneNumber)
+}
+
+// EditMessageRequest models the incoming JSON body editing a pending message;
+// omitted fields are left unchanged.
+type EditMessageRequest struct {
+	Content     *string `json:"content"`
+	PhoneNumber *string `json:"phone_number"`
+}
+
+// Validate checks the edit changes something and the result is a valid message.
+func (r EditMessageRequest) Validate() error {
+	if r.Content == nil && r.PhoneNumber == nil {
+		return errors.New("content or phone_number is required")
+	}
+	if r.Content != nil {
+		if err := validateContent(*r.Content); err != nil {
+			return err
+		}
+	}
+	if r.PhoneNumber != nil {
+		return validatePhoneNumber(*r.PhoneNumber)
+	}
+	return nil
+}
+
+// validateContent checks the content is set and fits the messages table.
+func validateContent(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return errors.New("content is required")
+	}
+	if n := utf8.RuneCountInString(content); n > MaxContentLength {
+		return fmt.Errorf("content must be at most %d characters, got %d", MaxContentLength, n)
+	}
+	return nil
 }
+
+// validatePhoneNumber checks the phone number is set and in E.164 format.
+func validatePhoneNumber(phoneNumber string) error {
+	if phoneNumber == "" {
+		return errors.New("phone_number is required")
+	}
+	if !phoneNumberPattern.MatchString(phoneNumber) {
+		return errors.New("phone_number must be in E.164 format, e.g. +15551234567")
+	}
+	return nil
+}
+
+// MessageLookupRequest models the incoming JSON body of a batch message lookup.
+type MessageLookupRequest struct {
+	IDs []string `json:"ids"`
+}
+
 type SendMessage struct {
 	To      string `json:"to"`
 	Content string `json:"content"`
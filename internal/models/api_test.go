@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateMessageRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateMessageRequest
+		wantErr bool
+	}{
+		{"valid", CreateMessageRequest{Content: "Hello", PhoneNumber: "+15551234567"}, false},
+		{"longest content", CreateMessageRequest{Content: strings.Repeat("a", MaxContentLength), PhoneNumber: "+15551234567"}, false},
+		// the limit counts characters, not bytes
+		{"multibyte content", CreateMessageRequest{Content: strings.Repeat("ş", MaxContentLength), PhoneNumber: "+905551234567"}, false},
+		{"content too long", CreateMessageRequest{Content: strings.Repeat("a", MaxContentLength+1), PhoneNumber: "+15551234567"}, true},
+		{"empty content", CreateMessageRequest{Content: "", PhoneNumber: "+15551234567"}, true},
+		{"blank content", CreateMessageRequest{Content: " \t\n", PhoneNumber: "+15551234567"}, true},
+		{"empty phone number", CreateMessageRequest{Content: "Hello"}, true},
+		{"missing plus", CreateMessageRequest{Content: "Hello", PhoneNumber: "15551234567"}, true},
+		{"leading zero", CreateMessageRequest{Content: "Hello", PhoneNumber: "+05551234567"}, true},
+		{"too short", CreateMessageRequest{Content: "Hello", PhoneNumber: "+12345"}, true},
+		{"too long", CreateMessageRequest{Content: "Hello", PhoneNumber: "+1234567890123456"}, true},
+		{"formatted", CreateMessageRequest{Content: "Hello", PhoneNumber: "+1 555 123 4567"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEditMessageRequestValidate(t *testing.T) {
+	content, blank, phone := "Hello", " ", "not a number"
+	tests := []struct {
+		name    string
+		req     EditMessageRequest
+		wantErr bool
+	}{
+		{"content only", EditMessageRequest{Content: &content}, false},
+		{"nothing to change", EditMessageRequest{}, true},
+		{"blank content", EditMessageRequest{Content: &blank}, true},
+		{"invalid phone number", EditMessageRequest{Content: &content, PhoneNumber: &phone}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
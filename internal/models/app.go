@@ -1,11 +1,47 @@
 package models
 
 type AppConfigStruct struct {
-	AppName           string
-	LogLevel          string
-	WebhookURL        string
-	ServerGracePeriod int
-	MessageFetchLimit int
-	CronInterval      int
-	MaxConcurrentJobs int
+	AppName                string
+	LogLevel               string
+	WebhookURL             string
+	ServerGracePeriod      int
+	MessageFetchLimit      int
+	CronInterval           int
+	CronSchedule           string
+	CronTimezone           string
+	MaxConcurrentJobs      int
+	SendJobTimeout         int
+	ProjectJobTimeout      int
+	ReconcileJobTimeout    int
+	PurgeJobTimeout        int
+	StuckJobThreshold      int
+	ProviderRateLimit      int
+	ProviderRateBurst      int
+	DistributedRateLimit   bool
+	ProjectorInterval      int
+	ReconcileInterval      int
+	ReconcileSchedule      string
+	ReconcilePolicy        string
+	StaleClaimAfter        int
+	LeaderElection         bool
+	LeaderLeaseTTL         int
+	InstanceID             string
+	APIKeyAuth             bool
+	AdminToken             string
+	SendMode               string
+	SendTimeBudget         int
+	BacklogAgeTarget       int
+	AdaptiveMaxBatch       int
+	AdaptiveLatencyTarget  int
+	EventDispatch          bool
+	DispatchDebounce       int
+	ImportMaxBytes         int64
+	EventStreamLength      int64
+	RateLimitWindow        int
+	RateLimitMessagesRead  int
+	RateLimitMessagesWrite int
+	RateLimitCronRead      int
+	RateLimitCronAdmin     int
+	TrustedProxies         string
+	JobRunRetention        int
 }
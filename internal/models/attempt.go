@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// MaxAttemptBodySize is the number of response body bytes stored per delivery attempt.
+const MaxAttemptBodySize = 1024
+
+// Error classes of a delivery attempt.
+const (
+	AttemptErrorNone        = "none"
+	AttemptErrorTimeout     = "timeout"
+	AttemptErrorNetwork     = "network"
+	AttemptErrorRequest     = "request"
+	AttemptErrorRateLimited = "rate_limited"
+	AttemptErrorClient      = "client_error"
+	AttemptErrorServer      = "server_error"
+	AttemptErrorUnexpected  = "unexpected_status"
+)
+
+// DeliveryAttempt is a single request made to a provider for a message.
+type DeliveryAttempt struct {
+	ID             int64     `json:"id"`
+	MessageID      string    `json:"messageId"`
+	AttemptNumber  int       `json:"attemptNumber"`
+	Endpoint       string    `json:"endpoint"`
+	IdempotencyKey string    `json:"idempotencyKey"`
+	RequestedAt    time.Time `json:"requestedAt"`
+	LatencyMs      int64     `json:"latencyMs"`
+	HTTPStatus     int       `json:"httpStatus,omitempty"`
+	ResponseBody   string    `json:"responseBody,omitempty"`
+	ErrorClass     string    `json:"errorClass"`
+	Error          string    `json:"error,omitempty"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// message lifecycle event types
+const (
+	EventClaimed = "claimed"
+	EventSent    = "sent"
+	EventFailed  = "failed"
+	// EventDelivered is reserved for provider delivery receipts, which are not received yet.
+	EventDelivered = "delivered"
+)
+
+// MessageEvent is a step in the lifecycle of a message, pushed to event stream subscribers.
+type MessageEvent struct {
+	ID                string    `json:"id"`
+	Status            string    `json:"status"`
+	MessageID         string    `json:"messageId"`
+	TenantID          string    `json:"tenantId"`
+	PhoneNumber       string    `json:"phoneNumber"`
+	ProviderMessageID string    `json:"providerMessageId,omitempty"`
+	Error             string    `json:"error,omitempty"`
+	Time              time.Time `json:"time"`
+}
+
+// EventFilter selects the events a subscriber receives; empty fields match everything.
+type EventFilter struct {
+	TenantID    string
+	PhoneNumber string
+	Statuses    []string
+}
+
+// Match reports whether ev passes the filter.
+func (f EventFilter) Match(ev MessageEvent) bool {
+	if f.TenantID != "" && ev.TenantID != f.TenantID {
+		return false
+	}
+	if f.PhoneNumber != "" && ev.PhoneNumber != f.PhoneNumber {
+		return false
+	}
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, s := range f.Statuses {
+		if s == ev.Status {
+			return true
+		}
+	}
+	return false
+}
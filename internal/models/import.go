@@ -0,0 +1,18 @@
+package models
+
+// ImportRowError describes why a row of an import was rejected.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportReport is the outcome of a bulk message import.
+type ImportReport struct {
+	Format   string `json:"format"`
+	Total    int    `json:"total"`
+	Imported int    `json:"imported"`
+	Rejected int    `json:"rejected"`
+	// Errors lists the rejected rows by line number, up to a limit.
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errorsTruncated"`
+}
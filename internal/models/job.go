@@ -0,0 +1,98 @@
+package models
+
+import (
+	pkgUtils "messaging-server/pkg/utils"
+	"time"
+)
+
+// JobStatus is a snapshot of a scheduled job's state.
+type JobStatus struct {
+	Name          string     `json:"name"`
+	Schedule      string     `json:"schedule"`
+	Running       bool       `json:"running"`
+	NextRun       string     `json:"nextRun,omitempty"`
+	LastRun       string     `json:"lastRun,omitempty"`
+	InFlight      int        `json:"inFlight"`
+	MaxConcurrent int        `json:"maxConcurrent"`
+	Skipped       int        `json:"skipped"`
+	Failures      int        `json:"failures"`
+	Panics        int        `json:"panics"`
+	StuckTotal    int        `json:"stuckTotal"`
+	StuckRuns     []StuckRun `json:"stuckRuns"`
+}
+
+// StuckRun is an in-flight run the watchdog flagged for exceeding its threshold.
+type StuckRun struct {
+	RunID      int64  `json:"runId"`
+	StartedAt  string `json:"startedAt"`
+	RunningFor int64  `json:"runningForSeconds"`
+}
+
+// Job run statuses stored in the job_runs.status column.
+const (
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+	RunStatusSkipped   = "skipped"
+	RunStatusTimedOut  = "timed_out"
+	RunStatusCancelled = "cancelled"
+)
+
+// maxErrorSummary caps the error summary stored per job run.
+const maxErrorSummary = 2000
+
+// JobRun is a single execution of a scheduled job.
+type JobRun struct {
+	ID           int64      `json:"id"`
+	JobName      string     `json:"jobName"`
+	Status       string     `json:"status"`
+	StartedAt    time.Time  `json:"startedAt"`
+	EndedAt      *time.Time `json:"endedAt,omitempty"`
+	DurationMs   int64      `json:"durationMs"`
+	Fetched      int        `json:"fetched"`
+	Sent         int        `json:"sent"`
+	Failed       int        `json:"failed"`
+	Skipped      bool       `json:"skipped"`
+	ErrorSummary string     `json:"errorSummary,omitempty"`
+	InstanceID   string     `json:"instanceId,omitempty"`
+	FencingToken int64      `json:"fencingToken,omitempty"`
+}
+
+// RecordError adds an error to the run's summary without failing the run.
+func (r *JobRun) RecordError(err error) {
+	if len(r.ErrorSummary) >= maxErrorSummary {
+		return
+	}
+	if r.ErrorSummary != "" {
+		r.ErrorSummary += "; "
+	}
+	r.ErrorSummary += pkgUtils.SanitizeText(err.Error())
+	r.ErrorSummary = pkgUtils.TruncateUTF8(r.ErrorSummary, maxErrorSummary)
+}
+
+// Fail records an error that aborted the run.
+func (r *JobRun) Fail(err error) {
+	r.Status = RunStatusFailed
+	r.RecordError(err)
+}
+
+// Finish stamps the end of the run and settles its status.
+func (r *JobRun) Finish() {
+	ended := time.Now()
+	r.EndedAt = &ended
+	r.DurationMs = ended.Sub(r.StartedAt).Milliseconds()
+	if r.Status == RunStatusRunning {
+		r.Status = RunStatusSucceeded
+	}
+}
+
+// JobRunFilter selects job runs for listing.
+type JobRunFilter struct {
+	JobName string
+	Status  string
+	Skipped *bool
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+	Offset  int
+}
@@ -0,0 +1,11 @@
+package models
+
+// LeaderStatus describes the leader election as seen by this instance.
+type LeaderStatus struct {
+	Enabled      bool   `json:"enabled"`
+	InstanceID   string `json:"instanceId"`
+	IsLeader     bool   `json:"isLeader"`
+	Leader       string `json:"leader"`
+	FencingToken int64  `json:"fencingToken"`
+	Error        string `json:"error,omitempty"`
+}
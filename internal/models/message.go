@@ -1,8 +1,118 @@
 package models
 
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message statuses stored in the messages.status column.
+const (
+	StatusPending = "pending"
+	StatusSending = "sending"
+	StatusSent    = "sent"
+	// StatusCancelled marks a message cancelled before it was sent.
+	StatusCancelled = "cancelled"
+)
+
+// Send modes of the send job.
+const (
+	// SendModeBatch claims a single batch per run.
+	SendModeBatch = "batch"
+	// SendModeDrain claims batches until the queue is empty or the time budget is exhausted.
+	SendModeDrain = "drain"
+	// SendModeAdaptive drains with a batch size following the backlog age and provider health.
+	SendModeAdaptive = "adaptive"
+)
+
 type Message struct {
-	ID          string
-	Content     string
+	ID             string    `json:"id"`
+	TenantID       string    `json:"tenantId"`
+	Content        string    `json:"content"`
+	PhoneNumber    string    `json:"phoneNumber"`
+	IsSent         bool      `json:"isSent"`
+	Status         string    `json:"status"`
+	SendGeneration int       `json:"sendGeneration"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// IdempotencyKey returns the key sent with every provider request of the
+// message. Retries of the same generation share the key so providers can
+// deduplicate them; deliberately re-sending a message bumps its generation.
+func (m Message) IdempotencyKey() string {
+	return fmt.Sprintf("%s:%d", m.ID, m.SendGeneration)
+}
+
+// Sources of the delivery details of a message.
+const (
+	DetailsSourceRedis    = "redis"
+	DetailsSourcePostgres = "postgres"
+)
+
+// MessageDetails is a message merged with its delivery details. The provider
+// message ID and send time come from the Redis record while it exists and
+// from Postgres once it expired; DetailsSource tells which one was used.
+type MessageDetails struct {
+	ID                string     `json:"id"`
+	TenantID          string     `json:"tenantId"`
+	Content           string     `json:"content"`
+	PhoneNumber       string     `json:"phoneNumber"`
+	Status            string     `json:"status"`
+	IsSent            bool       `json:"isSent"`
+	SendGeneration    int        `json:"sendGeneration"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	ClaimedAt         *time.Time `json:"claimedAt,omitempty"`
+	ProviderMessageID string     `json:"providerMessageId,omitempty"`
+	SentAt            string     `json:"sentAt,omitempty"`
+	DetailsSource     string     `json:"detailsSource,omitempty"`
+	// Version changes on every update of the message and is exposed as its ETag.
+	Version int `json:"version"`
+}
+
+// ETag returns the entity tag of the message's current version.
+func (d MessageDetails) ETag() string {
+	return fmt.Sprintf("%q", strconv.Itoa(d.Version))
+}
+
+// MessageFilter selects the messages of a listing page, newest first.
+type MessageFilter struct {
+	TenantID    string
+	Status      string
 	PhoneNumber string
-	IsSent      bool
+	From        *time.Time // created at or after
+	To          *time.Time // created before
+	Query       string     // case-insensitive substring of the content
+	Limit       int
+	After       *MessageCursor // start after this message
+}
+
+// MessageCursor points at the last message of a listing page.
+type MessageCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Encode returns the opaque form of the cursor handed out to clients.
+func (c MessageCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+// ParseMessageCursor decodes a cursor returned by Encode.
+func ParseMessageCursor(s string) (MessageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return MessageCursor{}, errors.New("malformed cursor")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return MessageCursor{}, errors.New("malformed cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return MessageCursor{}, errors.New("malformed cursor")
+	}
+	return MessageCursor{CreatedAt: t, ID: id}, nil
 }
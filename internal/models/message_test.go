@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMessageCursorRoundTrip(t *testing.T) {
+	cursors := []MessageCursor{
+		{CreatedAt: time.Date(2026, 3, 29, 1, 59, 59, 123456789, time.UTC), ID: "msg-001"},
+		{CreatedAt: time.Date(2026, 10, 25, 2, 30, 0, 0, time.FixedZone("CEST", 2*3600)), ID: "a|b"},
+		{CreatedAt: time.Unix(0, 0).UTC(), ID: "3f1c2d9e-0000-4000-8000-000000000000"},
+	}
+	for _, c := range cursors {
+		got, err := ParseMessageCursor(c.Encode())
+		if err != nil {
+			t.Fatalf("ParseMessageCursor(%q): %v", c.Encode(), err)
+		}
+		if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID {
+			t.Fatalf("round trip of %+v = %+v", c, got)
+		}
+	}
+}
+
+func TestParseMessageCursorMalformed(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, s := range []string{
+		"",
+		"not base64!",
+		encode("2026-01-01T00:00:00Z"),
+		encode("2026-01-01T00:00:00Z|"),
+		encode("yesterday|msg-001"),
+	} {
+		if _, err := ParseMessageCursor(s); err == nil {
+			t.Errorf("ParseMessageCursor(%q) accepted a malformed cursor", s)
+		}
+	}
+}
+
+func TestMessageJSONMatchesDetails(t *testing.T) {
+	keys := func(v any) map[string]bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+		set := map[string]bool{}
+		for k := range fields {
+			set[k] = true
+		}
+		return set
+	}
+
+	details := keys(MessageDetails{})
+	for k := range keys(Message{}) {
+		if !details[k] {
+			t.Errorf("Message field %q is not a MessageDetails field", k)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+// ProviderRateStats describes the outbound throughput of a single provider.
+type ProviderRateStats struct {
+	Provider    string  `json:"provider"`
+	Limit       int     `json:"limit"`
+	Burst       int     `json:"burst"`
+	Distributed bool    `json:"distributed"`
+	CurrentRate float64 `json:"currentRate"`
+	SentLastMin int     `json:"sentLastMinute"`
+	PausedUntil string  `json:"pausedUntil,omitempty"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RateLimitStatus is the state of the sliding window of an API client after a request.
+type RateLimitStatus struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the oldest request leaves the window and frees a slot.
+	Reset time.Duration
+}
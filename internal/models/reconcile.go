@@ -0,0 +1,32 @@
+package models
+
+// Reconciliation policies.
+const (
+	ReconcilePolicyReport = "report"
+	ReconcilePolicyRepair = "repair"
+)
+
+// DriftEntry describes a single message whose Redis and Postgres state disagree.
+type DriftEntry struct {
+	ID                string `json:"id"`
+	Status            string `json:"status,omitempty"`
+	ProviderMessageID string `json:"providerMessageId,omitempty"`
+	SentAt            string `json:"sentAt,omitempty"`
+	ClaimedAt         string `json:"claimedAt,omitempty"`
+	Action            string `json:"action"`
+}
+
+// ReconciliationReport is the outcome of one reconciliation run.
+type ReconciliationReport struct {
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt"`
+	Policy     string `json:"policy"`
+	// RedisOnly lists messages Redis reports as sent while Postgres does not.
+	RedisOnly []DriftEntry `json:"redisOnly"`
+	// PostgresOnly lists messages Postgres reports as sent with no Redis record.
+	PostgresOnly []DriftEntry `json:"postgresOnly"`
+	// StaleClaims lists messages stuck in sending with no evidence of the provider outcome.
+	StaleClaims []DriftEntry `json:"staleClaims"`
+	Repaired    int          `json:"repaired"`
+	Errors      []string     `json:"errors,omitempty"`
+}
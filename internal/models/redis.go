@@ -8,7 +8,11 @@ type RedisConfigStruct struct {
 	TTL      int
 }
 
+// RedisRecord is the sent outcome of a message as projected into Redis.
+// ID is our message ID, MessageID the one assigned by the provider.
 type RedisRecord struct {
-	MessageID string `json:"messageId"`
-	SentAt    string `json:"sentAt"`
+	ID             string `json:"-"`
+	MessageID      string `json:"messageId"`
+	SentAt         string `json:"sentAt"`
+	IdempotencyKey string `json:"-"`
 }
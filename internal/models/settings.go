@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Keys of the settings that can be changed at runtime.
+const (
+	SettingCronInterval      = "cron_interval"
+	SettingMessageFetchLimit = "message_fetch_limit"
+	SettingMaxConcurrentJobs = "max_concurrent_jobs"
+)
+
+// RuntimeSettings holds the scheduler and sending parameters in effect.
+type RuntimeSettings struct {
+	CronInterval      int `json:"cronInterval"`
+	MessageFetchLimit int `json:"messageFetchLimit"`
+	MaxConcurrentJobs int `json:"maxConcurrentJobs"`
+}
+
+// SettingsUpdate models the incoming JSON body changing runtime settings;
+// omitted fields are left unchanged.
+type SettingsUpdate struct {
+	CronInterval      *int `json:"cronInterval"`
+	MessageFetchLimit *int `json:"messageFetchLimit"`
+	MaxConcurrentJobs *int `json:"maxConcurrentJobs"`
+}
+
+// SettingChange is a single setting changed by an update.
+type SettingChange struct {
+	Key      string
+	OldValue string
+	NewValue string
+}
+
+// SettingAudit records who changed a runtime setting and when.
+type SettingAudit struct {
+	ID         int64     `json:"id"`
+	Key        string    `json:"key"`
+	OldValue   string    `json:"oldValue"`
+	NewValue   string    `json:"newValue"`
+	Actor      string    `json:"actor"`
+	RemoteAddr string    `json:"remoteAddr,omitempty"`
+	ChangedAt  time.Time `json:"changedAt"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTenant owns the messages and API keys not assigned to another tenant.
+const DefaultTenant = "default"
+
+// tenantIDPattern matches tenant IDs: lowercase letters, digits and dashes.
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Tenant is a team sharing the deployment, with its own messages, API keys and provider.
+// An empty WebhookURL sends through WEBHOOK_URL; a nil RateLimit shares the
+// PROVIDER_RATE_LIMIT of the provider with the other tenants.
+type Tenant struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	WebhookURL string    `json:"webhookUrl,omitempty"`
+	RateLimit  *int      `json:"rateLimit,omitempty"`
+	RateBurst  *int      `json:"rateBurst,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// TenantConfig models the incoming JSON body configuring a tenant; omitted
+// fields fall back to the deployment defaults.
+type TenantConfig struct {
+	Name       string `json:"name"`
+	WebhookURL string `json:"webhookUrl"`
+	RateLimit  *int   `json:"rateLimit"`
+	RateBurst  *int   `json:"rateBurst"`
+}
+
+// Validate checks the tenant is named and its provider settings are usable.
+func (c TenantConfig) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(c.Name) > 255 {
+		return errors.New("name must be at most 255 characters")
+	}
+	if c.WebhookURL != "" {
+		u, err := url.Parse(c.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("webhookUrl must be an absolute http or https URL")
+		}
+	}
+	if c.RateLimit != nil && *c.RateLimit < 0 {
+		return errors.New("rateLimit must be >= 0")
+	}
+	if c.RateBurst != nil {
+		if c.RateLimit == nil {
+			return errors.New("rateBurst requires rateLimit")
+		}
+		if *c.RateBurst < 0 {
+			return errors.New("rateBurst must be >= 0")
+		}
+	}
+	return nil
+}
+
+// CreateTenantRequest models the incoming JSON body creating a tenant.
+type CreateTenantRequest struct {
+	ID string `json:"id"`
+	TenantConfig
+}
+
+// Validate checks the tenant ID and configuration.
+func (r CreateTenantRequest) Validate() error {
+	if !tenantIDPattern.MatchString(r.ID) {
+		return errors.New("id must be 1-64 lowercase letters, digits or dashes, starting with a letter or digit")
+	}
+	return r.TenantConfig.Validate()
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTenantConfigValidate(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		name    string
+		config  TenantConfig
+		wantErr bool
+	}{
+		{"name only", TenantConfig{Name: "Billing"}, false},
+		{"full", TenantConfig{Name: "Billing", WebhookURL: "https://hooks.example.com/sms", RateLimit: intPtr(10), RateBurst: intPtr(20)}, false},
+		{"unlimited", TenantConfig{Name: "Billing", RateLimit: intPtr(0)}, false},
+		{"missing name", TenantConfig{}, true},
+		{"blank name", TenantConfig{Name: "  "}, true},
+		{"name too long", TenantConfig{Name: strings.Repeat("n", 256)}, true},
+		{"relative webhook", TenantConfig{Name: "Billing", WebhookURL: "/sms"}, true},
+		{"webhook scheme", TenantConfig{Name: "Billing", WebhookURL: "ftp://hooks.example.com/sms"}, true},
+		{"webhook without host", TenantConfig{Name: "Billing", WebhookURL: "https:///sms"}, true},
+		{"negative rate", TenantConfig{Name: "Billing", RateLimit: intPtr(-1)}, true},
+		{"burst without rate", TenantConfig{Name: "Billing", RateBurst: intPtr(5)}, true},
+		{"negative burst", TenantConfig{Name: "Billing", RateLimit: intPtr(5), RateBurst: intPtr(-1)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package ratelimit
+
+import (
+	"context"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rateWindow is the window used to compute the observed send rate.
+const rateWindow = time.Minute
+
+// Limiter is a token bucket limiting the outbound throughput of one provider.
+// It is shared by every job running in the process and, when distributed is
+// set, by every replica through Redis.
+type Limiter struct {
+	provider    string
+	rate        int // tokens per second, 0 means unlimited
+	burst       int
+	distributed bool
+
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	sent        []time.Time // send timestamps within rateWindow
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Limiter{}
+)
+
+// ForProvider returns the limiter of the given provider, creating it from
+// the application configuration on first use.
+func ForProvider(provider string) *Limiter {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if l, ok := registry[provider]; ok {
+		return l
+	}
+	return register(provider, configs.AppConfig.ProviderRateLimit, configs.AppConfig.ProviderRateBurst)
+}
+
+// ForTenant returns the limiter of a tenant with its own rate limit at the
+// given provider. A changed limit of the tenant is applied to the limiter.
+func ForTenant(provider, tenant string, rate, burst int) *Limiter {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name := provider + "/" + tenant
+	if burst <= 0 {
+		burst = rate
+	}
+	if l, ok := registry[name]; ok {
+		l.mu.Lock()
+		l.rate, l.burst = rate, burst
+		l.mu.Unlock()
+		return l
+	}
+	return register(name, rate, burst)
+}
+
+// register creates the limiter of the given name; registryMu must be held.
+func register(name string, rate, burst int) *Limiter {
+	if burst <= 0 {
+		burst = rate
+	}
+
+	l := &Limiter{
+		provider:    name,
+		rate:        rate,
+		burst:       burst,
+		distributed: configs.AppConfig.DistributedRateLimit,
+		tokens:      float64(burst),
+		last:        time.Now(),
+	}
+	registry[name] = l
+	return l
+}
+
+// Stats returns the current throughput of every known provider.
+func Stats() []models.ProviderRateStats {
+	registryMu.Lock()
+	limiters := make([]*Limiter, 0, len(registry))
+	for _, l := range registry {
+		limiters = append(limiters, l)
+	}
+	registryMu.Unlock()
+
+	stats := make([]models.ProviderRateStats, 0, len(limiters))
+	for _, l := range limiters {
+		stats = append(stats, l.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Provider < stats[j].Provider })
+	return stats
+}
+
+// Wait blocks until the provider may receive one more message.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		d := l.reserve(ctx)
+		if d <= 0 {
+			l.record()
+			return nil
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Pause stops sending to the provider for d, e.g. after a 429 response.
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.mu.Unlock()
+
+	log.Logger.Warningf("provider %s throttled us; pausing sends for %s", l.provider, d)
+
+	if l.distributed {
+		if err := database.RedisClient.PauseProvider(context.Background(), l.provider, d); err != nil {
+			log.Logger.Errorf("failed to share pause of provider %s: %v", l.provider, err)
+		}
+	}
+}
+
+// Stats returns the current throughput of the provider.
+func (l *Limiter) Stats() models.ProviderRateStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.trim(now)
+
+	stats := models.ProviderRateStats{
+		Provider:    l.provider,
+		Limit:       l.rate,
+		Burst:       l.burst,
+		Distributed: l.distributed,
+		CurrentRate: float64(len(l.sent)) / rateWindow.Seconds(),
+		SentLastMin: len(l.sent),
+	}
+	if l.pausedUntil.After(now) {
+		stats.PausedUntil = l.pausedUntil.Format(time.RFC3339)
+	}
+	return stats
+}
+
+// reserve takes a token if one is available and otherwise returns how long
+// the caller has to wait before trying again.
+func (l *Limiter) reserve(ctx context.Context) time.Duration {
+	l.mu.Lock()
+	now := time.Now()
+	if l.pausedUntil.After(now) {
+		defer l.mu.Unlock()
+		return l.pausedUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return 0
+	}
+	if !l.distributed {
+		defer l.mu.Unlock()
+		return l.reserveLocal(now)
+	}
+	rate, burst := l.rate, l.burst
+	l.mu.Unlock()
+
+	wait, err := database.RedisClient.TakeToken(ctx, l.provider, rate, burst)
+	if err != nil {
+		log.Logger.Warningf("shared rate limit unavailable for %s, using local bucket: %v", l.provider, err)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.reserveLocal(time.Now())
+	}
+	return wait
+}
+
+// reserveLocal refills the in-process bucket and takes a token from it.
+// The caller must hold l.mu.
+func (l *Limiter) reserveLocal(now time.Time) time.Duration {
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / float64(l.rate) * float64(time.Second))
+}
+
+// record stores the time of a send for the observed rate.
+func (l *Limiter) record() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.trim(now)
+	l.sent = append(l.sent, now)
+}
+
+// trim drops send timestamps older than rateWindow. The caller must hold l.mu.
+func (l *Limiter) trim(now time.Time) {
+	cutoff := now.Add(-rateWindow)
+	i := 0
+	for i < len(l.sent) && l.sent[i].Before(cutoff) {
+		i++
+	}
+	l.sent = l.sent[i:]
+}
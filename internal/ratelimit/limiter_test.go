@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReserveLocal(t *testing.T) {
+	start := time.Now()
+	l := &Limiter{provider: "test", rate: 2, burst: 3, tokens: 3, last: start}
+
+	// the full burst is available right away
+	for i := 0; i < 3; i++ {
+		if d := l.reserveLocal(start); d != 0 {
+			t.Fatalf("reserve %d of the burst waited %s", i+1, d)
+		}
+	}
+
+	// an empty bucket refills at rate tokens per second
+	if d := l.reserveLocal(start); d != 500*time.Millisecond {
+		t.Fatalf("empty bucket wait = %s, want 500ms", d)
+	}
+	if d := l.reserveLocal(start.Add(250 * time.Millisecond)); d != 250*time.Millisecond {
+		t.Fatalf("half refilled bucket wait = %s, want 250ms", d)
+	}
+	if d := l.reserveLocal(start.Add(500 * time.Millisecond)); d != 0 {
+		t.Fatalf("refilled bucket waited %s", d)
+	}
+}
+
+func TestReserveLocalCapsAtBurst(t *testing.T) {
+	start := time.Now()
+	l := &Limiter{provider: "test", rate: 10, burst: 2, tokens: 0, last: start}
+
+	// an hour idle still only refills the burst
+	later := start.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if d := l.reserveLocal(later); d != 0 {
+			t.Fatalf("reserve %d waited %s", i+1, d)
+		}
+	}
+	if d := l.reserveLocal(later); d <= 0 {
+		t.Fatalf("reserve beyond the burst did not wait")
+	}
+}
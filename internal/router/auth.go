@@ -0,0 +1,139 @@
+package router
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/database"
+	"messaging-server/internal/handler"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// lastUsedResolution is how often the last use of a key is recorded at most.
+const lastUsedResolution = time.Minute
+
+// requireScope lets through requests carrying an API key granted scope, either as
+// "Authorization: Bearer <key>" or "X-API-Key: <key>". When streamToken is set a
+// stream token issued by POST /api/v1/events/token may be passed as the token
+// query parameter instead, for clients like EventSource that can not set
+// headers; API keys are never accepted in the URL, where they would be logged.
+// ADMIN_TOKEN passes every scope, so the first keys can be created with it. With
+// API_KEY_AUTH disabled every request passes.
+//
+// The request acts for the tenant of its API key. Requests made with ADMIN_TOKEN
+// or without authentication act for the tenant named by the X-Tenant-ID header,
+// the default tenant when there is none.
+func requireScope(scope string, streamToken bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !configs.AppConfig.APIKeyAuth {
+			if setHeaderTenant(c) {
+				c.Next()
+			}
+			return
+		}
+
+		given := c.GetHeader("X-API-Key")
+		if given == "" {
+			given, _ = strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if given == "" && streamToken && c.Query("token") != "" {
+			key, err := database.RedisClient.TakeStreamToken(c.Request.Context(), c.Query("token"))
+			if errors.Is(err, database.ErrStreamTokenNotFound) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid, expired or used stream token"})
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check stream token", "details": err.Error()})
+				return
+			}
+			if grant(c, key, scope) {
+				c.Next()
+			}
+			return
+		}
+		if given == "" {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing API key"})
+			return
+		}
+
+		if token := configs.AppConfig.AdminToken; token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			if setHeaderTenant(c) {
+				c.Next()
+			}
+			return
+		}
+
+		// keys are looked up by digest, so comparing them does not leak timing
+		key, err := database.PostgresConnection.FetchActiveAPIKey(c.Request.Context(), models.HashAPIKey(given))
+		if errors.Is(err, database.ErrAPIKeyNotFound) {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or revoked API key"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check API key", "details": err.Error()})
+			return
+		}
+		if !grant(c, key, scope) {
+			return
+		}
+
+		if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > lastUsedResolution {
+			go func() {
+				if err := database.PostgresConnection.TouchAPIKey(context.Background(), key.ID); err != nil {
+					log.Logger.Warningf("failed to record use of API key %d: %v", key.ID, err)
+				}
+			}()
+		}
+		c.Next()
+	}
+}
+
+// grant makes the request act as key when it was granted scope and belongs to
+// the tenant the request names, if any. Otherwise it aborts the request and
+// returns false. Stream tokens issued with ADMIN_TOKEN act as a key without ID.
+func grant(c *gin.Context, key models.APIKey, scope string) bool {
+	if !key.HasScope(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + scope + " scope"})
+		return false
+	}
+	if tenant := c.GetHeader("X-Tenant-ID"); tenant != "" && tenant != key.TenantID {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key belongs to tenant " + key.TenantID})
+		return false
+	}
+
+	if key.ID != 0 {
+		c.Set(handler.APIKeyContextKey, key)
+	}
+	c.Set(handler.TenantContextKey, key.TenantID)
+	return true
+}
+
+// setHeaderTenant makes the request act for the tenant named by the X-Tenant-ID
+// header. It aborts the request and returns false when the tenant does not exist.
+func setHeaderTenant(c *gin.Context) bool {
+	tenant := c.GetHeader("X-Tenant-ID")
+	if tenant == "" || tenant == models.DefaultTenant {
+		c.Set(handler.TenantContextKey, models.DefaultTenant)
+		return true
+	}
+
+	_, err := database.PostgresConnection.FetchTenant(c.Request.Context(), tenant)
+	if errors.Is(err, database.ErrTenantNotFound) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "unknown tenant " + tenant})
+		return false
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check tenant", "details": err.Error()})
+		return false
+	}
+	c.Set(handler.TenantContextKey, tenant)
+	return true
+}
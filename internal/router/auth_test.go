@@ -0,0 +1,137 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/handler"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	log.InitLogger()
+}
+
+// withConfig applies change to the application configuration for the duration of the test.
+func withConfig(t *testing.T, change func(cfg *models.AppConfigStruct)) {
+	t.Helper()
+	saved := configs.AppConfig
+	t.Cleanup(func() { configs.AppConfig = saved })
+	change(&configs.AppConfig)
+}
+
+// serveScope sends req through requireScope and reports the tenant the request acted for.
+func serveScope(scope string, streamToken bool, req *http.Request) (*httptest.ResponseRecorder, string) {
+	var tenant string
+	r := gin.New()
+	r.GET("/", requireScope(scope, streamToken), func(c *gin.Context) {
+		tenant = c.GetString(handler.TenantContextKey)
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, tenant
+}
+
+func TestRequireScopeAuthDisabled(t *testing.T) {
+	withConfig(t, func(cfg *models.AppConfigStruct) { cfg.APIKeyAuth = false })
+
+	w, tenant := serveScope(models.ScopeCronAdmin, false, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || tenant != models.DefaultTenant {
+		t.Fatalf("status %d, tenant %q; want 200 for the default tenant", w.Code, tenant)
+	}
+}
+
+func TestRequireScopeMissingKey(t *testing.T) {
+	withConfig(t, func(cfg *models.AppConfigStruct) { cfg.APIKeyAuth = true })
+
+	w, _ := serveScope(models.ScopeMessagesRead, false, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Fatalf("status %d, WWW-Authenticate %q; want 401 with a Bearer challenge", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestRequireScopeIgnoresCredentialsInURL(t *testing.T) {
+	withConfig(t, func(cfg *models.AppConfigStruct) {
+		cfg.APIKeyAuth = true
+		cfg.AdminToken = "admin-secret"
+	})
+
+	// neither API keys nor the admin token are accepted as query parameters,
+	// and stream tokens only on the routes allowing them
+	for _, target := range []string{"/?api_key=admin-secret", "/?token=admin-secret"} {
+		w, _ := serveScope(models.ScopeMessagesRead, false, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s = %d, want 401", target, w.Code)
+		}
+	}
+}
+
+func TestRequireScopeAdminToken(t *testing.T) {
+	withConfig(t, func(cfg *models.AppConfigStruct) {
+		cfg.APIKeyAuth = true
+		cfg.AdminToken = "admin-secret"
+	})
+
+	for _, header := range []string{"Authorization", "X-API-Key"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header == "Authorization" {
+			req.Header.Set(header, "Bearer admin-secret")
+		} else {
+			req.Header.Set(header, "admin-secret")
+		}
+		w, tenant := serveScope(models.ScopeCronAdmin, false, req)
+		if w.Code != http.StatusOK || tenant != models.DefaultTenant {
+			t.Errorf("admin token in %s: status %d, tenant %q; want 200 for the default tenant", header, w.Code, tenant)
+		}
+	}
+}
+
+func TestGrant(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        models.APIKey
+		tenant     string // X-Tenant-ID header
+		wantStatus int
+		wantKey    bool
+	}{
+		{"granted", models.APIKey{ID: 7, TenantID: "billing", Scopes: []string{models.ScopeMessagesRead}}, "", http.StatusOK, true},
+		{"matching tenant", models.APIKey{ID: 7, TenantID: "billing", Scopes: []string{models.ScopeMessagesRead}}, "billing", http.StatusOK, true},
+		{"other tenant", models.APIKey{ID: 7, TenantID: "billing", Scopes: []string{models.ScopeMessagesRead}}, "support", http.StatusForbidden, false},
+		{"missing scope", models.APIKey{ID: 7, TenantID: "billing", Scopes: []string{models.ScopeMessagesWrite}}, "", http.StatusForbidden, false},
+		// stream tokens issued with ADMIN_TOKEN carry no key
+		{"admin stream token", models.APIKey{TenantID: "billing", Scopes: []string{models.ScopeMessagesRead}}, "", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tenant string
+			var hasKey bool
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) {
+				if grant(c, tt.key, models.ScopeMessagesRead) {
+					tenant = c.GetString(handler.TenantContextKey)
+					_, hasKey = c.Get(handler.APIKeyContextKey)
+					c.Status(http.StatusOK)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus || hasKey != tt.wantKey {
+				t.Fatalf("status %d, key set %v; want %d, %v", w.Code, hasKey, tt.wantStatus, tt.wantKey)
+			}
+			if w.Code == http.StatusOK && tenant != tt.key.TenantID {
+				t.Fatalf("tenant %q, want %q", tenant, tt.key.TenantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/database"
+	"messaging-server/internal/handler"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// rateLimitTimeout bounds checking the sliding window of a request.
+	rateLimitTimeout = 500 * time.Millisecond
+	// rateLimitCooldown is how long requests skip the rate limit after Redis failed,
+	// so they do not each wait for a reconnect to fail.
+	rateLimitCooldown = 10 * time.Second
+)
+
+// rateLimitDownUntil is when, in Unix nanoseconds, the rate limit tries Redis again.
+var rateLimitDownUntil atomic.Int64
+
+// slideFunc records a request of client against its sliding window.
+type slideFunc func(ctx context.Context, client string, limit int, window time.Duration) (models.RateLimitStatus, error)
+
+// scopeLimit returns how many requests a client may make to the endpoints of
+// scope per RATE_LIMIT_WINDOW; 0 means unlimited.
+func scopeLimit(scope string) int {
+	switch scope {
+	case models.ScopeMessagesRead:
+		return configs.AppConfig.RateLimitMessagesRead
+	case models.ScopeMessagesWrite:
+		return configs.AppConfig.RateLimitMessagesWrite
+	case models.ScopeCronRead:
+		return configs.AppConfig.RateLimitCronRead
+	case models.ScopeCronAdmin:
+		return configs.AppConfig.RateLimitCronAdmin
+	}
+	return 0
+}
+
+// rateLimit limits the requests each client makes to the endpoints of scope
+// within a sliding window kept in Redis, so the limit holds across replicas.
+// Clients are told apart by their API key, or by their IP address when the
+// request was made without one. It must run after requireScope.
+//
+// Every limited response carries the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers; rejected requests answer 429 with Retry-After. When
+// Redis is unavailable requests are let through rather than failing the API,
+// without asking Redis again for rateLimitCooldown.
+func rateLimit(scope string) gin.HandlerFunc {
+	return limitRequests(scope, func(ctx context.Context, client string, limit int, window time.Duration) (models.RateLimitStatus, error) {
+		return database.RedisClient.SlideWindow(ctx, client, limit, window)
+	})
+}
+
+// limitRequests is rateLimit backed by slide.
+func limitRequests(scope string, slide slideFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, window := scopeLimit(scope), time.Duration(configs.AppConfig.RateLimitWindow)*time.Second
+		if limit <= 0 || window <= 0 {
+			c.Next()
+			return
+		}
+
+		client := "ip:" + c.ClientIP()
+		if key, ok := c.Get(handler.APIKeyContextKey); ok {
+			client = fmt.Sprintf("key:%d", key.(models.APIKey).ID)
+		}
+
+		if time.Now().UnixNano() < rateLimitDownUntil.Load() {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), rateLimitTimeout)
+		status, err := slide(ctx, scope+":"+client, limit, window)
+		cancel()
+		if err != nil {
+			rateLimitDownUntil.Store(time.Now().Add(rateLimitCooldown).UnixNano())
+			log.Logger.Warningf("API rate limit unavailable, letting requests through for %s: %v", rateLimitCooldown, err)
+			c.Next()
+			return
+		}
+
+		reset := strconv.Itoa(ceilSeconds(status.Reset))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit, int(window.Seconds())))
+		c.Header("RateLimit-Limit", strconv.Itoa(status.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(status.Remaining))
+		c.Header("RateLimit-Reset", reset)
+		if !status.Allowed {
+			c.Header("Retry-After", reset)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":   "rate limit exceeded",
+				"details": fmt.Sprintf("at most %d %s requests per %s; retry in %ss", limit, scope, window, reset),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds, as the rate limit headers expect.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,142 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"messaging-server/internal/handler"
+	"messaging-server/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCeilSeconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{0, 0},
+		{time.Nanosecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{59*time.Second + time.Millisecond, 60},
+	}
+	for _, tt := range tests {
+		if got := ceilSeconds(tt.d); got != tt.want {
+			t.Errorf("ceilSeconds(%s) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
+
+// serveLimited sends a request through limitRequests for the messages:read scope,
+// acting as the API key with the given ID unless it is 0.
+func serveLimited(slide slideFunc, keyID int64) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		if keyID != 0 {
+			c.Set(handler.APIKeyContextKey, models.APIKey{ID: keyID})
+		}
+	}, limitRequests(models.ScopeMessagesRead, slide), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:4321"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func withRateLimit(t *testing.T, limit, window int) {
+	t.Helper()
+	withConfig(t, func(cfg *models.AppConfigStruct) {
+		cfg.RateLimitMessagesRead = limit
+		cfg.RateLimitWindow = window
+	})
+	rateLimitDownUntil.Store(0)
+	t.Cleanup(func() { rateLimitDownUntil.Store(0) })
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	withRateLimit(t, 10, 60)
+
+	var client string
+	w := serveLimited(func(ctx context.Context, c string, limit int, window time.Duration) (models.RateLimitStatus, error) {
+		client = c
+		return models.RateLimitStatus{Allowed: true, Limit: limit, Remaining: 9, Reset: 1500 * time.Millisecond}, nil
+	}, 7)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+	if client != models.ScopeMessagesRead+":key:7" {
+		t.Fatalf("client %q, want the API key", client)
+	}
+	want := map[string]string{
+		"RateLimit-Policy":    "10;w=60",
+		"RateLimit-Limit":     "10",
+		"RateLimit-Remaining": "9",
+		"RateLimit-Reset":     "2",
+		"Retry-After":         "",
+	}
+	for name, value := range want {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestRateLimitExceeded(t *testing.T) {
+	withRateLimit(t, 10, 60)
+
+	var client string
+	w := serveLimited(func(ctx context.Context, c string, limit int, window time.Duration) (models.RateLimitStatus, error) {
+		client = c
+		return models.RateLimitStatus{Allowed: false, Limit: limit, Remaining: 0, Reset: 30*time.Second + time.Millisecond}, nil
+	}, 0)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", w.Code)
+	}
+	if client != models.ScopeMessagesRead+":ip:192.0.2.1" {
+		t.Fatalf("client %q, want the client IP", client)
+	}
+	if w.Header().Get("Retry-After") != "31" || w.Header().Get("RateLimit-Reset") != "31" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("headers %v, want Retry-After and RateLimit-Reset 31 with nothing remaining", w.Header())
+	}
+}
+
+func TestRateLimitUnlimited(t *testing.T) {
+	withRateLimit(t, 0, 60)
+
+	w := serveLimited(func(context.Context, string, int, time.Duration) (models.RateLimitStatus, error) {
+		t.Fatal("an unlimited scope must not check the window")
+		return models.RateLimitStatus{}, nil
+	}, 7)
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Fatalf("status %d, headers %v; want 200 without rate limit headers", w.Code, w.Header())
+	}
+}
+
+func TestRateLimitFailsOpenAndBacksOff(t *testing.T) {
+	withRateLimit(t, 10, 60)
+
+	calls := 0
+	slide := func(context.Context, string, int, time.Duration) (models.RateLimitStatus, error) {
+		calls++
+		return models.RateLimitStatus{}, errors.New("redis: connection refused")
+	}
+
+	for i := 0; i < 3; i++ {
+		w := serveLimited(slide, 7)
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("request %d: status %d, headers %v; want 200 without rate limit headers", i+1, w.Code, w.Header())
+		}
+	}
+	// only the first request waits for Redis; the others skip it during the cooldown
+	if calls != 1 {
+		t.Fatalf("window checked %d times, want 1", calls)
+	}
+}
@@ -5,17 +5,41 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
 	_ "messaging-server/docs"
+	"messaging-server/internal/configs"
 	"messaging-server/internal/cron"
+	"messaging-server/internal/events"
 	"messaging-server/internal/handler"
+	"messaging-server/internal/leader"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"strings"
 )
 
 // initEngine initializes the Gin engine without any routes
 func initEngine() *gin.Engine {
-	return gin.Default()
+	r := gin.New()
+	r.Use(accessLog(), handler.Recovery())
+
+	// only the configured proxies may set the client IP through X-Forwarded-For
+	if err := r.SetTrustedProxies(trustedProxies(configs.AppConfig.TrustedProxies)); err != nil {
+		log.Logger.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	return r
+}
+
+// trustedProxies splits the comma-separated TRUSTED_PROXIES; none when it is empty.
+func trustedProxies(value string) []string {
+	var proxies []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
 }
 
 // SetupRouter configures all routes under /api/v1 and returns the engine
-func SetupRouter(cronJob *cron.Cron) *gin.Engine {
+func SetupRouter(scheduler *cron.Scheduler, elector *leader.Elector, broker *events.Broker) *gin.Engine {
 	r := initEngine()
 
 	// index endpoint
@@ -32,10 +56,94 @@ func SetupRouter(cronJob *cron.Cron) *gin.Engine {
 		v1 := api.Group("/v1")
 		{
 			// cron control endpoint
-			v1.POST("/cron/control", handler.CronHandler(cronJob))
+			cronAdmin := v1.Group("", requireScope(models.ScopeCronAdmin, false), rateLimit(models.ScopeCronAdmin))
+			cronAdmin.POST("/cron/control", handler.CronHandler(scheduler))
+
+			cronRead := v1.Group("", requireScope(models.ScopeCronRead, false), rateLimit(models.ScopeCronRead))
+			{
+				// scheduled jobs status endpoints
+				cronRead.GET("/cron/jobs", handler.ListJobsHandler(scheduler, elector))
+				cronRead.GET("/cron/jobs/:name", handler.GetJobHandler(scheduler))
+
+				// leader election status endpoint
+				cronRead.GET("/cron/leader", handler.LeaderHandler(elector))
+
+				// job run history endpoints
+				cronRead.GET("/cron/runs", handler.ListRunsHandler())
+				cronRead.GET("/cron/runs/:id", handler.GetRunHandler())
+
+				// outbound provider send rate endpoint
+				cronRead.GET("/providers/rate", handler.ProviderRateHandler())
+			}
+
+			messagesRead := v1.Group("", requireScope(models.ScopeMessagesRead, false), rateLimit(models.ScopeMessagesRead))
+			{
+				// list sent messages endpoint, deprecated in favor of GET /messages
+				messagesRead.GET("/list/sent-messages", handler.ListMessageHandler())
+
+				// list messages endpoint
+				messagesRead.GET("/messages", handler.ListMessagesHandler())
+
+				// streaming message export endpoint
+				messagesRead.GET("/messages/export", handler.ExportMessagesHandler())
+
+				// message lookup endpoints
+				messagesRead.GET("/messages/:id", handler.GetMessageHandler())
+				messagesRead.POST("/messages/lookup", handler.LookupMessagesHandler())
+
+				// delivery attempts of a message endpoint
+				messagesRead.GET("/messages/:id/attempts", handler.ListAttemptsHandler())
+
+				// event stream token endpoint
+				messagesRead.POST("/events/token", handler.CreateStreamTokenHandler())
+			}
+
+			messagesWrite := v1.Group("", requireScope(models.ScopeMessagesWrite, false), rateLimit(models.ScopeMessagesWrite))
+			{
+				// create message endpoint
+				messagesWrite.POST("/messages", handler.CreateMessageHandler())
+
+				// bulk message import endpoint
+				messagesWrite.POST("/messages/import", handler.ImportMessagesHandler())
+
+				// edit and cancel pending message endpoints
+				messagesWrite.PATCH("/messages/:id", handler.EditMessageHandler())
+				messagesWrite.DELETE("/messages/:id", handler.CancelMessageHandler())
+			}
+
+			// live message event stream endpoints; browsers can only pass a stream token in the query
+			eventsRead := v1.Group("", requireScope(models.ScopeMessagesRead, true), rateLimit(models.ScopeMessagesRead))
+			{
+				eventsRead.GET("/events", handler.EventsHandler(broker))
+				eventsRead.GET("/events/ws", handler.EventsWebSocketHandler(broker))
+			}
+
+			// reconciliation report endpoint, protected by ADMIN_TOKEN; it spans every tenant
+			v1.GET("/admin/reconciliation", handler.AdminAuth(), handler.ReconciliationReportHandler())
+
+			// runtime settings endpoints, protected by ADMIN_TOKEN
+			settings := v1.Group("/admin/settings", handler.AdminAuth())
+			{
+				settings.GET("", handler.GetSettingsHandler())
+				settings.PATCH("", handler.UpdateSettingsHandler(scheduler))
+				settings.GET("/audit", handler.ListSettingsAuditHandler())
+			}
+
+			// API key management endpoints, protected by ADMIN_TOKEN
+			keys := v1.Group("/admin/keys", handler.AdminAuth())
+			{
+				keys.POST("", handler.CreateAPIKeyHandler())
+				keys.GET("", handler.ListAPIKeysHandler())
+				keys.DELETE("/:id", handler.RevokeAPIKeyHandler())
+			}
 
-			// list sent messages endpoint
-			v1.GET("/list/sent-messages", handler.ListMessageHandler())
+			// tenant management endpoints, protected by ADMIN_TOKEN
+			tenants := v1.Group("/admin/tenants", handler.AdminAuth())
+			{
+				tenants.POST("", handler.CreateTenantHandler())
+				tenants.GET("", handler.ListTenantsHandler())
+				tenants.PUT("/:id", handler.UpdateTenantHandler())
+			}
 		}
 
 	}
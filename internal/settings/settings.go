@@ -0,0 +1,214 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/cron"
+	"messaging-server/internal/database"
+	log "messaging-server/internal/logging"
+	"messaging-server/internal/models"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrInvalid is returned when an update carries a value out of range.
+var ErrInvalid = errors.New("invalid setting")
+
+var (
+	// updateMu serializes updates so persisted and applied values stay in step
+	updateMu sync.Mutex
+
+	mu      sync.RWMutex
+	current = models.RuntimeSettings{
+		CronInterval:      configs.AppConfig.CronInterval,
+		MessageFetchLimit: configs.AppConfig.MessageFetchLimit,
+		MaxConcurrentJobs: configs.AppConfig.MaxConcurrentJobs,
+	}
+)
+
+// Current returns the runtime settings in effect.
+func Current() models.RuntimeSettings {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// FetchLimit returns the number of messages a send run claims.
+func FetchLimit() int {
+	return Current().MessageFetchLimit
+}
+
+// reloadInterval is how often Watch picks up settings changed through another replica.
+const reloadInterval = 15 * time.Second
+
+// Load applies the overrides persisted by earlier updates to the send job,
+// so they survive restarts. Unknown keys and invalid values are skipped.
+func Load(ctx context.Context, send *cron.Cron) error {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	next, intervalSet, err := stored(ctx, Current())
+	if err != nil {
+		return err
+	}
+	if next != Current() || intervalSet {
+		log.Logger.Infof("runtime settings %+v override the configuration", next)
+	}
+	return apply(send, next, intervalSet)
+}
+
+// Reload applies the persisted settings when they differ from the ones in
+// effect, so an update served by another replica reaches this one.
+func Reload(ctx context.Context, send *cron.Cron) error {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	prev := Current()
+	next, _, err := stored(ctx, prev)
+	if err != nil {
+		return err
+	}
+	if next == prev {
+		return nil
+	}
+	log.Logger.Infof("runtime settings changed from %+v to %+v", prev, next)
+	return apply(send, next, next.CronInterval != prev.CronInterval)
+}
+
+// Watch reloads the persisted settings every reloadInterval until ctx is done.
+func Watch(ctx context.Context, send *cron.Cron) {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := Reload(ctx, send); err != nil {
+				log.Logger.Errorf("failed to reload runtime settings: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// stored returns base overridden by the persisted settings and whether an
+// interval was persisted. Unknown keys and unparsable values are skipped.
+func stored(ctx context.Context, base models.RuntimeSettings) (models.RuntimeSettings, bool, error) {
+	values, err := database.PostgresConnection.FetchRuntimeSettings(ctx)
+	if err != nil {
+		return base, false, err
+	}
+
+	next := base
+	var intervalSet bool
+	for key, value := range values {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			log.Logger.Warningf("ignoring runtime setting %s=%q: %v", key, value, err)
+			continue
+		}
+		switch key {
+		case models.SettingCronInterval:
+			intervalSet = true
+			next.CronInterval = n
+		case models.SettingMessageFetchLimit:
+			next.MessageFetchLimit = n
+		case models.SettingMaxConcurrentJobs:
+			next.MaxConcurrentJobs = n
+		default:
+			log.Logger.Warningf("ignoring unknown runtime setting %s", key)
+		}
+	}
+	if err := validate(next); err != nil {
+		return base, false, err
+	}
+	return next, intervalSet, nil
+}
+
+// Update validates and persists the settings present in update, writing an
+// audit entry per changed setting, then applies them to the send job: a new
+// interval re-arms its schedule and a new limit resizes its concurrency.
+func Update(ctx context.Context, send *cron.Cron, update models.SettingsUpdate, actor, remoteAddr string) (models.RuntimeSettings, error) {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	prev := Current()
+	next := prev
+	if update.CronInterval != nil {
+		next.CronInterval = *update.CronInterval
+	}
+	if update.MessageFetchLimit != nil {
+		next.MessageFetchLimit = *update.MessageFetchLimit
+	}
+	if update.MaxConcurrentJobs != nil {
+		next.MaxConcurrentJobs = *update.MaxConcurrentJobs
+	}
+	if err := validate(next); err != nil {
+		return prev, err
+	}
+
+	// only settings present in the update are persisted, even when unchanged,
+	// so a value set explicitly keeps overriding the environment
+	var changes []models.SettingChange
+	add := func(key string, set *int, old int) {
+		if set != nil {
+			changes = append(changes, models.SettingChange{Key: key, OldValue: strconv.Itoa(old), NewValue: strconv.Itoa(*set)})
+		}
+	}
+	add(models.SettingCronInterval, update.CronInterval, prev.CronInterval)
+	add(models.SettingMessageFetchLimit, update.MessageFetchLimit, prev.MessageFetchLimit)
+	add(models.SettingMaxConcurrentJobs, update.MaxConcurrentJobs, prev.MaxConcurrentJobs)
+	if len(changes) == 0 {
+		return prev, nil
+	}
+
+	if err := database.PostgresConnection.SaveRuntimeSettings(ctx, changes, actor, remoteAddr); err != nil {
+		return prev, err
+	}
+	for _, change := range changes {
+		log.Logger.Infof("runtime setting %s changed from %s to %s by %s", change.Key, change.OldValue, change.NewValue, actor)
+	}
+
+	if err := apply(send, next, update.CronInterval != nil); err != nil {
+		return prev, err
+	}
+	return next, nil
+}
+
+// validate checks the ranges of the settings. Like the override of a manual
+// run, the fetch limit is capped at ADAPTIVE_MAX_BATCH.
+func validate(s models.RuntimeSettings) error {
+	if s.CronInterval <= 0 {
+		return fmt.Errorf("%w: cronInterval must be > 0, got %d", ErrInvalid, s.CronInterval)
+	}
+	if limit := configs.AppConfig.AdaptiveMaxBatch; s.MessageFetchLimit <= 0 || s.MessageFetchLimit > limit {
+		return fmt.Errorf("%w: messageFetchLimit must be between 1 and %d (ADAPTIVE_MAX_BATCH), got %d", ErrInvalid, limit, s.MessageFetchLimit)
+	}
+	if s.MaxConcurrentJobs <= 0 {
+		return fmt.Errorf("%w: maxConcurrentJobs must be > 0, got %d", ErrInvalid, s.MaxConcurrentJobs)
+	}
+	return nil
+}
+
+// apply makes next the settings in effect. A changed interval replaces the
+// send job's schedule, including one given as a cron expression.
+func apply(send *cron.Cron, next models.RuntimeSettings, intervalChanged bool) error {
+	if intervalChanged {
+		schedule, err := cron.Every(next.CronInterval)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		send.SetSchedule(schedule)
+	}
+	if err := send.SetMaxConcurrent(next.MaxConcurrentJobs); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	mu.Lock()
+	current = next
+	mu.Unlock()
+	return nil
+}
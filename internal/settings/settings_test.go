@@ -0,0 +1,35 @@
+package settings
+
+import (
+	"errors"
+	"messaging-server/internal/configs"
+	"messaging-server/internal/models"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	max := configs.AppConfig.AdaptiveMaxBatch
+	tests := []struct {
+		name     string
+		settings models.RuntimeSettings
+		wantErr  bool
+	}{
+		{"valid", models.RuntimeSettings{CronInterval: 120, MessageFetchLimit: 2, MaxConcurrentJobs: 1}, false},
+		{"zero interval", models.RuntimeSettings{CronInterval: 0, MessageFetchLimit: 2, MaxConcurrentJobs: 1}, true},
+		{"negative fetch limit", models.RuntimeSettings{CronInterval: 120, MessageFetchLimit: -1, MaxConcurrentJobs: 1}, true},
+		{"largest fetch limit", models.RuntimeSettings{CronInterval: 120, MessageFetchLimit: max, MaxConcurrentJobs: 1}, false},
+		{"fetch limit above ADAPTIVE_MAX_BATCH", models.RuntimeSettings{CronInterval: 120, MessageFetchLimit: max + 1, MaxConcurrentJobs: 1}, true},
+		{"zero concurrency", models.RuntimeSettings{CronInterval: 120, MessageFetchLimit: 2, MaxConcurrentJobs: 0}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Fatalf("validate() = %v, want it to wrap ErrInvalid", err)
+			}
+		})
+	}
+}
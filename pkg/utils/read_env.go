@@ -25,3 +25,15 @@ func GetEnvInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+func GetEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		} else {
+			log.Fatal("Error converting environment variable", key, "to bool:", err)
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// TruncateUTF8 shortens s to at most max bytes without splitting a multi-byte character.
+func TruncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	n := max
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// SanitizeText makes s storable as Postgres text: invalid UTF-8 sequences and
+// NUL characters, which Postgres rejects, are replaced.
+func SanitizeText(s string) string {
+	s = strings.ToValidUTF8(s, "�")
+	return strings.ReplaceAll(s, "\x00", "�")
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 5, "hello"},
+		{"hello", 3, "hel"},
+		{"aé", 2, "a"},  // é is 2 bytes
+		{"a€b", 3, "a"}, // € is 3 bytes
+		{"a€b", 4, "a€"},
+		{"😀", 3, ""},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := TruncateUTF8(tt.in, tt.max); got != tt.want {
+			t.Errorf("TruncateUTF8(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateUTF8AlwaysValid(t *testing.T) {
+	s := strings.Repeat("ğüşçö€😀", 200)
+	for max := 0; max <= len(s); max += 7 {
+		got := TruncateUTF8(s, max)
+		if len(got) > max || !utf8.ValidString(got) {
+			t.Fatalf("TruncateUTF8(..., %d) returned %d bytes, valid=%v", max, len(got), utf8.ValidString(got))
+		}
+	}
+}
+
+func TestSanitizeText(t *testing.T) {
+	got := SanitizeText("ok\x00\xffend")
+	if !utf8.ValidString(got) || strings.ContainsRune(got, 0) {
+		t.Fatalf("SanitizeText returned %q", got)
+	}
+	if !strings.HasPrefix(got, "ok") || !strings.HasSuffix(got, "end") {
+		t.Fatalf("SanitizeText lost text: %q", got)
+	}
+}